@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"errors"
+	"forumProject/dao/mysql"
+	"forumProject/logic"
+	"forumProject/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateDemoHandler 创建
+func CreateDemoHandler(c *gin.Context) {
+
+	// 1. 获取参数和参数校验
+	p := new(models.ParamDemo)
+	if err := c.ShouldBindJSON(p); err != nil {
+		invalidParam(c, err)
+		return
+	}
+
+	// 2. 业务逻辑
+	d, err := logic.CreateDemo(c.Request.Context(), p)
+	if err != nil {
+		zap.L().Error("logic.CreateDemo failed", zap.Error(err))
+		busy(c)
+		return
+	}
+
+	// 3. 返回值
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": d,
+	})
+}
+
+// DemoDetailHandler 详情
+func DemoDetailHandler(c *gin.Context) {
+	id, ok := demoID(c)
+	if !ok {
+		return
+	}
+
+	d, err := logic.GetDemo(c.Request.Context(), id)
+	if errors.Is(err, mysql.ErrorDemoNotExist) {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "记录不存在",
+		})
+		return
+	}
+	if err != nil {
+		zap.L().Error("logic.GetDemo failed", zap.Uint64("id", id), zap.Error(err))
+		busy(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": d,
+	})
+}
+
+// DemoListHandler 分页列表，?page=1&size=10
+func DemoListHandler(c *gin.Context) {
+	p := new(models.ParamDemoList)
+	if err := c.ShouldBindQuery(p); err != nil {
+		invalidParam(c, err)
+		return
+	}
+
+	list, err := logic.ListDemos(c.Request.Context(), p)
+	if err != nil {
+		zap.L().Error("logic.ListDemos failed", zap.Error(err))
+		busy(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": list,
+	})
+}
+
+// UpdateDemoHandler 修改
+func UpdateDemoHandler(c *gin.Context) {
+	id, ok := demoID(c)
+	if !ok {
+		return
+	}
+	p := new(models.ParamDemo)
+	if err := c.ShouldBindJSON(p); err != nil {
+		invalidParam(c, err)
+		return
+	}
+
+	d, err := logic.UpdateDemo(c.Request.Context(), id, p)
+	if errors.Is(err, mysql.ErrorDemoNotExist) {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "记录不存在",
+		})
+		return
+	}
+	if err != nil {
+		zap.L().Error("logic.UpdateDemo failed", zap.Uint64("id", id), zap.Error(err))
+		busy(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": d,
+	})
+}
+
+// DeleteDemoHandler 删除
+func DeleteDemoHandler(c *gin.Context) {
+	id, ok := demoID(c)
+	if !ok {
+		return
+	}
+
+	err := logic.DeleteDemo(c.Request.Context(), id)
+	if errors.Is(err, mysql.ErrorDemoNotExist) {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "记录不存在",
+		})
+		return
+	}
+	if err != nil {
+		zap.L().Error("logic.DeleteDemo failed", zap.Uint64("id", id), zap.Error(err))
+		busy(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg": "success",
+	})
+}
+
+// demoID 解析路径中的ID，无效时直接返回错误响应
+func demoID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "无效的ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
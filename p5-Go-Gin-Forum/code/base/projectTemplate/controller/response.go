@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	// gen:if validator
+	"github.com/go-playground/validator/v10"
+	// gen:end
+)
+
+// invalidParam 参数校验失败的响应
+func invalidParam(c *gin.Context, err error) {
+	// gen:if validator
+	// 判断err类型是否是validator内置的类型，是的话翻译成中文
+	if errs, ok := err.(validator.ValidationErrors); ok {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": removeTopStruct(errs.Translate(trans)),
+		})
+		return
+	}
+	// gen:end
+	c.JSON(http.StatusOK, gin.H{
+		"msg": err.Error(),
+	})
+}
+
+// busy 服务内部错误的响应，具体原因只写日志
+func busy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"msg": "服务繁忙",
+	})
+}
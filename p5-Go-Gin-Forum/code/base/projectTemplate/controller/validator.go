@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// 定义一个全局翻译器T
+var trans ut.Translator
+
+// InitTrans 初始化翻译器
+func InitTrans(locale string) (err error) {
+	// 修改gin框架中的Validator引擎属性，实现自定制
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// 注册一个获取json tag的自定义方法
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	zhT := zh.New() // 中文翻译器
+	enT := en.New() // 英文翻译器
+
+	// 第一个参数是备用（fallback）的语言环境，后面的参数是应该支持的语言环境
+	uni := ut.New(enT, zhT, enT)
+	trans, ok = uni.GetTranslator(locale)
+	if !ok {
+		return fmt.Errorf("uni.GetTranslator(%s) failed", locale)
+	}
+
+	// 注册翻译器
+	switch locale {
+	case "zh":
+		err = zhTranslations.RegisterDefaultTranslations(v, trans)
+	default:
+		err = enTranslations.RegisterDefaultTranslations(v, trans)
+	}
+	return
+}
+
+// removeTopStruct 去掉字段名中的结构体名称前缀，如 ParamDemo.name -> name
+func removeTopStruct(fields map[string]string) map[string]string {
+	res := map[string]string{}
+	for field, err := range fields {
+		res[field[strings.Index(field, ".")+1:]] = err
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"forumProject/models"
+)
+
+var ErrorDemoNotExist = errors.New("记录不存在")
+
+// CreateDemo ID为0时由数据库自增生成
+func CreateDemo(ctx context.Context, d *models.Demo) error {
+	sqlStr := `insert into demo(id, name) values(?, ?)`
+	res, err := db.ExecContext(ctx, sqlStr, d.ID, d.Name)
+	if err != nil {
+		return err
+	}
+	if d.ID == 0 {
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		d.ID = uint64(id)
+	}
+	return nil
+}
+
+func GetDemoByID(ctx context.Context, id uint64) (*models.Demo, error) {
+	d := new(models.Demo)
+	sqlStr := `select id, name, coalesce(create_time, now()) as create_time from demo where id = ?`
+	err := db.GetContext(ctx, d, sqlStr, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrorDemoNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDemos 按ID倒序分页
+func ListDemos(ctx context.Context, offset, limit int) ([]*models.Demo, error) {
+	list := make([]*models.Demo, 0, limit)
+	sqlStr := `select id, name, coalesce(create_time, now()) as create_time from demo order by id desc limit ?, ?`
+	err := db.SelectContext(ctx, &list, sqlStr, offset, limit)
+	return list, err
+}
+
+func UpdateDemo(ctx context.Context, d *models.Demo) error {
+	sqlStr := `update demo set name = ? where id = ?`
+	_, err := db.ExecContext(ctx, sqlStr, d.Name, d.ID)
+	return err
+}
+
+func DeleteDemo(ctx context.Context, id uint64) error {
+	sqlStr := `delete from demo where id = ?`
+	res, err := db.ExecContext(ctx, sqlStr, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrorDemoNotExist
+	}
+	return nil
+}
@@ -1,28 +1,24 @@
 package redis
 
 import (
-	"fmt"
 	"forumProject/settings"
+	kitredis "kit/redis"
 
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
 )
 
 // 声明一个全局的rdb变量
-var rdb *redis.Client
+var rdb redis.UniversalClient
 
 // Init 初始化连接
 func Init(cfg *settings.RedisConfig) (err error) {
-	rdb = redis.NewClient(&redis.Options{
-		Addr: fmt.Sprintf("%s:%d",
-			cfg.Host,
-			cfg.Port,
-		),
+	rdb, err = kitredis.New(kitredis.Options{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
 		Password: cfg.Password, // no password set
 		DB:       cfg.DB,       // use default DB
 		PoolSize: cfg.PoolSize,
 	})
-
-	_, err = rdb.Ping().Result()
 	return
 }
 
@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"context"
+	"forumProject/dao/mysql"
+	"forumProject/models"
+	// gen:if snowflake
+	"forumProject/pkg/snowflake"
+	// gen:end
+)
+
+// 列表默认每页的条数
+const defaultPageSize = 10
+
+func CreateDemo(ctx context.Context, p *models.ParamDemo) (*models.Demo, error) {
+	d := &models.Demo{Name: p.Name}
+	// gen:if snowflake
+	// 用雪花算法生成ID，不依赖数据库自增
+	id, err := snowflake.GetID()
+	if err != nil {
+		return nil, err
+	}
+	d.ID = id
+	// gen:end
+	if err := mysql.CreateDemo(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func GetDemo(ctx context.Context, id uint64) (*models.Demo, error) {
+	return mysql.GetDemoByID(ctx, id)
+}
+
+func ListDemos(ctx context.Context, p *models.ParamDemoList) ([]*models.Demo, error) {
+	page, size := p.Page, p.Size
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	return mysql.ListDemos(ctx, (page-1)*size, size)
+}
+
+func UpdateDemo(ctx context.Context, id uint64, p *models.ParamDemo) (*models.Demo, error) {
+	d, err := mysql.GetDemoByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	d.Name = p.Name
+	if err := mysql.UpdateDemo(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func DeleteDemo(ctx context.Context, id uint64) error {
+	return mysql.DeleteDemo(ctx, id)
+}
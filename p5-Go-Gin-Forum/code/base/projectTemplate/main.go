@@ -10,18 +10,24 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
+	// gen:if validator
+	"forumProject/controller"
+	// gen:end
+	// gen:if mysql
 	"forumProject/dao/mysql"
+	// gen:end
+	// gen:if redis
 	"forumProject/dao/redis"
-	"forumProject/logger"
+	// gen:end
+	// gen:if snowflake
+	"forumProject/pkg/snowflake"
+	// gen:end
 	"forumProject/routes"
 	"forumProject/settings"
-	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	kitlogger "kit/logger"
+	"kit/server"
 	"time"
 
 	"go.uber.org/zap"
@@ -40,9 +46,19 @@ func main() {
 		return
 	}
 	fmt.Println("settings init success...")
+	// 启动时使用同一份配置；运行中要读最新配置时调用 settings.Get()
+	conf := settings.Get()
 
 	// 2. 初始化日志
-	if err := logger.Init(settings.Conf.LogConfig, settings.Conf.Mode); err != nil {
+	lc := conf.LogConfig
+	if _, err := kitlogger.Init(kitlogger.Options{
+		Level:      lc.Level,
+		Filename:   lc.Filename,
+		MaxSize:    lc.MaxSize,
+		MaxAge:     lc.MaxAge,
+		MaxBackups: lc.MaxBackups,
+		Console:    conf.Mode == "dev", // 开发模式同时输出到终端
+	}); err != nil {
 		fmt.Printf("init logger failed, err:%#v\n", err)
 		return
 	}
@@ -50,56 +66,54 @@ func main() {
 	zap.L().Debug("logger init success...")
 
 	/*
-		3. 初始化数据库链接
+		3. 初始化数据库链接和各个组件，gen 生成项目时会去掉没有选择的组件
 	*/
+	// gen:if mysql
 	// 3.1 初始化MySQL连接（sqlx）
-	if err := mysql.Init(settings.Conf.MySQLConfig); err != nil {
+	if err := mysql.Init(conf.MySQLConfig); err != nil {
 		fmt.Printf("init mysql failed, err:%v\n", err)
 		return
 	}
 	defer mysql.Close()
 	zap.L().Debug("mysql init success...")
+	// gen:end
 
+	// gen:if redis
 	// 3.2 初始化Redis连接（go-redis）
-	if err := redis.Init(settings.Conf.RedisConfig); err != nil {
+	if err := redis.Init(conf.RedisConfig); err != nil {
 		fmt.Printf("init redis failed, err:%v\n", err)
 		return
 	}
 	defer redis.Close()
 	zap.L().Debug("redis init success...")
+	// gen:end
+
+	// gen:if snowflake
+	// 雪花算法初始化：生成不重复的ID
+	if err := snowflake.Init(conf.StartTime, uint16(conf.MachineID)); err != nil {
+		fmt.Printf("init snowflake failed, err:%v\n", err)
+		return
+	}
+	// gen:end
+
+	// gen:if validator
+	// 注册翻译器，参数校验失败时返回中文提示
+	if err := controller.InitTrans("zh"); err != nil {
+		fmt.Printf("init validator InitTrans failed, err:%v\n", err)
+		return
+	}
+	// gen:end
 
 	// 4. 路由注册
-	r := routes.Setup(settings.Conf.Mode)
+	r := routes.Setup(conf.Mode)
 	zap.L().Debug("routes init success...")
 
 	// 5. 启动服务（优雅关机）
-	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", settings.Conf.Port),
-		Handler: r,
+	if err := server.Run(r, server.Options{
+		Addr:     fmt.Sprintf(":%d", conf.Port),
+		WaitTime: func() time.Duration { return time.Duration(settings.Get().WaitTime) * time.Second },
+	}); err != nil {
+		// 不用Fatal，否则defer的清理都不会执行
+		zap.L().Error("server exited", zap.Error(err))
 	}
-	go func() {
-		// 开启一个goroutine启动服务
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			zap.L().Fatal("listen: " + err.Error())
-		}
-	}()
-	//
-	// 等待中断信号来优雅地关闭服务器，为关闭服务器操作设置一个5秒的超时
-	quit := make(chan os.Signal, 1) // 创建一个接收信号的通道
-	// kill 默认会发送 syscall.SIGTERM 信号
-	// kill -2 发送 syscall.SIGINT 信号，我们常用的Ctrl+C就是触发系统SIGINT信号
-	// kill -9 发送 syscall.SIGKILL 信号，但是不能被捕获，所以不需要添加它
-	// signal.Notify把收到的 syscall.SIGINT或syscall.SIGTERM 信号转发给quit
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM) // 此处不会阻塞
-	<-quit                                               // 阻塞在此，当接收到上述两种信号时才会往下执行
-	zap.L().Info(fmt.Sprintf("触发关闭等待服务，将等待%ds", settings.Conf.WaitTime))
-	// 创建一个5秒超时的context
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	// 5秒内优雅关闭服务（将未处理完的请求处理完再关闭服务），超过5秒就超时退出
-	if err := srv.Shutdown(ctx); err != nil {
-		zap.L().Fatal(fmt.Sprintf("等了%ds了还没好，先撤了...", settings.Conf.WaitTime), zap.Error(err))
-	}
-
-	zap.L().Info("所有请求处理完成,服务正常退出")
 }
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `demo`;
+CREATE TABLE `demo` (
+                        `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+                        `name` varchar(64) COLLATE utf8mb4_general_ci NOT NULL,
+                        `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+                        `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                        PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Demo 示例资源，gen 生成项目时按 -resource 重命名
+type Demo struct {
+	ID         uint64    `json:"id,string" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
+
+// ParamDemo 创建和修改时的参数
+type ParamDemo struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+// ParamDemoList 列表的分页参数
+type ParamDemoList struct {
+	Page int `form:"page" binding:"omitempty,min=1"`
+	Size int `form:"size" binding:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,38 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+
+	sf "github.com/sony/sonyflake"
+)
+
+// StartTimeLayout 配置中 start_time 的格式
+const StartTimeLayout = "2006-01-02"
+
+var sonyFlake *sf.Sonyflake // 实例
+
+// Init 需传入开始时间和当前的机器ID，多实例部署时机器ID不能重复
+func Init(startTime string, machineID uint16) (err error) {
+	t, err := time.Parse(StartTimeLayout, startTime) // 初始化一个开始的时间
+	if err != nil {
+		return fmt.Errorf("invalid start_time %q: %w", startTime, err)
+	}
+	sonyFlake = sf.NewSonyflake(sf.Settings{ // 生成sonyflake节点
+		StartTime: t,
+		MachineID: func() (uint16, error) { return machineID, nil },
+	})
+	if sonyFlake == nil {
+		return fmt.Errorf("start_time %s is in the future", startTime)
+	}
+	return
+}
+
+// GetID 返回生成的id值
+func GetID() (id uint64, err error) {
+	if sonyFlake == nil {
+		err = fmt.Errorf("snowflake not inited")
+		return
+	}
+	return sonyFlake.NextID()
+}
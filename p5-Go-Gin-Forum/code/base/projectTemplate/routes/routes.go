@@ -10,12 +10,15 @@
 package routes
 
 import (
-	"forumProject/logger"
+	// gen:if resource
+	"forumProject/controller"
+	// gen:end
 	"forumProject/settings"
+	kitlogger "kit/logger"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 func Setup(mode string) *gin.Engine {
@@ -23,12 +26,20 @@ func Setup(mode string) *gin.Engine {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.New()
-	r.Use(logger.GinLogger(), logger.GinRecovery(true))
+	// 零值的 GinOptions 按内置列表对令牌、Cookie、密码等打码，可以用 kitlogger.NewRedactor 追加
+	r.Use(kitlogger.GinLogger(zap.L(), kitlogger.GinOptions{}), kitlogger.GinRecovery(zap.L(), true, kitlogger.GinOptions{}))
 
 	r.GET("/version", func(c *gin.Context) {
-		time.Sleep(10 * time.Second)
-		c.String(http.StatusOK, settings.Conf.Version)
+		c.String(http.StatusOK, settings.Get().Version)
 	})
 
+	// gen:if resource
+	r.POST("/demo", controller.CreateDemoHandler)
+	r.GET("/demo", controller.DemoListHandler)
+	r.GET("/demo/:id", controller.DemoDetailHandler)
+	r.PUT("/demo/:id", controller.UpdateDemoHandler)
+	r.DELETE("/demo/:id", controller.DeleteDemoHandler)
+	// gen:end
+
 	return r
 }
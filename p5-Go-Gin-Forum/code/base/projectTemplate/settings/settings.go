@@ -11,25 +11,35 @@ package settings
 
 import (
 	"fmt"
-
-	"github.com/fsnotify/fsnotify"
-	"github.com/spf13/viper"
+	kitsettings "kit/settings"
+	"sync/atomic"
 )
 
-// Conf 全局变量，用来保存程序的所有配置信息
-var Conf = new(AppConfig)
+// current 当前生效的配置，热加载时整个替换，其他goroutine通过 Get 读取
+var current atomic.Pointer[AppConfig]
+
+// Get 返回当前生效的配置，不要修改返回的对象
+func Get() *AppConfig {
+	return current.Load()
+}
 
 type AppConfig struct {
-	Name         string `mapstructure:"name"`
-	Mode         string `mapstructure:"mode"`
-	Version      string `mapstructure:"version"`
-	Port         int    `mapstructure:"port"`
-	StartTime    string `mapstructure:"start_time"`
-	MachineID    int64  `mapstructure:"machine_id"`
-	WaitTime     int    `mapstructure:"wait_time"`
-	*LogConfig   `mapstructure:"log"`
+	Name    string `mapstructure:"name"`
+	Mode    string `mapstructure:"mode"`
+	Version string `mapstructure:"version"`
+	Port    int    `mapstructure:"port"`
+	// gen:if snowflake
+	StartTime string `mapstructure:"start_time"`
+	MachineID int64  `mapstructure:"machine_id"`
+	// gen:end
+	WaitTime   int `mapstructure:"wait_time"`
+	*LogConfig `mapstructure:"log"`
+	// gen:if mysql
 	*MySQLConfig `mapstructure:"mysql"`
+	// gen:end
+	// gen:if redis
 	*RedisConfig `mapstructure:"redis"`
+	// gen:end
 }
 
 type LogConfig struct {
@@ -40,6 +50,7 @@ type LogConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 }
 
+// gen:if mysql
 type MySQLConfig struct {
 	Host         string `mapstructure:"host"`
 	User         string `mapstructure:"user"`
@@ -50,6 +61,9 @@ type MySQLConfig struct {
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
 }
 
+// gen:end
+
+// gen:if redis
 type RedisConfig struct {
 	Host     string `mapstructure:"host"`
 	Password string `mapstructure:"password"`
@@ -58,41 +72,25 @@ type RedisConfig struct {
 	PoolSize int    `mapstructure:"pool_size"`
 }
 
-func Init(configFileName string) (err error) {
-
-	// 1.相对路径（是相对于执行的位置）
-	viper.SetConfigFile(configFileName)
+// gen:end
 
-	// 2.注意不要重名
-	//viper.SetConfigName("config")
-	//viper.AddConfigPath(".")
-	//viper.AddConfigPath("./conf")
-
-	// 3.远程配置中心获取 使用什么格式去解析
-	//viper.SetConfigType("yaml") // json
-
-	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			fmt.Println("Not Found config! ")
-		} else {
-			fmt.Printf("Fatal error config file: %s \n", err)
-		}
-	}
-
-	// 把读取到的配置信息反序列化到 Conf 变量中
-	if err := viper.Unmarshal(Conf); err != nil {
-		fmt.Printf("viper.Unmarshal failed, err:%v\n", err)
+func Init(configFileName string) (err error) {
+	loader := kitsettings.NewLoader[AppConfig](kitsettings.Options{File: configFileName})
+	conf, err := loader.Load()
+	if err != nil {
+		return
 	}
+	current.Store(conf)
 
-	viper.WatchConfig()
-	// 小回调的钩子
-	viper.OnConfigChange(func(in fsnotify.Event) {
-		fmt.Println("配置文件修改了，重新加载到全局Conf ...")
-		if err := viper.Unmarshal(Conf); err != nil {
-			fmt.Printf("viper.Unmarshal failed, err:%v\n", err)
+	// 配置文件修改后重新加载，解析失败时保留原来的配置
+	loader.Watch(func() {
+		next, err := loader.Reload()
+		if err != nil {
+			fmt.Printf("reload config failed, err:%v\n", err)
+			return
 		}
+		current.Store(next)
+		fmt.Println("配置文件修改了，重新加载配置 ...")
 	})
-
 	return nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"forumProject/settings"
+	"os"
+)
+
+// 子命令的用法说明
+const commandUsage = `usage: forumProject [-config file] [command]
+
+commands:
+  (none)         启动服务
+  config check   校验配置文件，失败时退出码为1，可用于CI
+  export         导出用户、社区、帖子、评论（NDJSON或CSV），-h 查看参数
+  import         导入export导出的数据，支持 -dry-run 只校验，-h 查看参数
+`
+
+// runCommand 执行子命令，返回进程退出码
+func runCommand(configFileName string, args []string) int {
+	switch args[0] {
+	case "config":
+		return configCommand(configFileName, args[1:])
+	case "export":
+		return exportCommand(configFileName, args[1:])
+	case "import":
+		return importCommand(configFileName, args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(commandUsage)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], commandUsage)
+	return 2
+}
+
+func configCommand(configFileName string, args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config check", flag.ContinueOnError)
+	fs.StringVar(&configFileName, "config", configFileName, "配置文件")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if err := settings.Check(configFileName); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", configFileName, err)
+		return 1
+	}
+	fmt.Printf("%s: config ok\n", configFileName)
+	return 0
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"forumProject/dao/mysql"
+	"forumProject/dao/redis"
+	"forumProject/logic"
+	"forumProject/pkg/dataio"
+	snowflake "forumProject/pkg/sonwflake"
+	"forumProject/settings"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+// exportCommand forumProject export [-type all|users|communities|posts|comments] [-format ndjson|csv] [-out path]
+// 导出单类数据时 -out 是文件，"-" 表示标准输出；导出全部时 -out 是目录，每类数据一个文件
+func exportCommand(configFileName string, args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&configFileName, "config", configFileName, "配置文件")
+	kind := fs.String("type", "all", "导出的数据：all、users、communities、posts、comments")
+	format := fs.String("format", dataio.FormatNDJSON, "文件格式：ndjson、csv")
+	out := fs.String("out", "", "输出文件（-type all 时为目录），默认标准输出（-type all 时为当前目录）")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	kinds, err := dataKinds(*kind)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err = initData(configFileName, false, false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer mysql.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	for _, k := range kinds {
+		path := dataPath(*out, k, *format, len(kinds) > 1)
+		if err = exportOne(ctx, k, *format, path); err != nil {
+			fmt.Fprintf(os.Stderr, "export %s failed: %v\n", k, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func exportOne(ctx context.Context, kind, format, path string) error {
+	var f io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		f = file
+	}
+
+	w, err := dataio.NewWriter(format, f)
+	if err != nil {
+		return err
+	}
+	n, err := logic.Export(ctx, kind, w)
+	if err != nil {
+		return err
+	}
+	// 统计信息写到标准错误，不混进导出的数据里
+	fmt.Fprintf(os.Stderr, "exported %d %s to %s\n", n, kind, path)
+	return nil
+}
+
+// importCommand forumProject import [-type ...] [-format ...] [-in path] [-batch n] [-dry-run] [-new-ids] [-id-map file]
+// 每 -batch 条数据一个事务；校验失败的行跳过并报告，有失败的行时退出码为1
+func importCommand(configFileName string, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.StringVar(&configFileName, "config", configFileName, "配置文件")
+	kind := fs.String("type", "all", "导入的数据：all、users、communities、posts、comments")
+	format := fs.String("format", dataio.FormatNDJSON, "文件格式：ndjson、csv")
+	in := fs.String("in", "", "输入文件（-type all 时为目录），默认标准输入（-type all 时为当前目录）")
+	batch := fs.Int("batch", 500, "每个事务导入的条数")
+	dryRun := fs.Bool("dry-run", false, "只校验数据并报告错误，不连接数据库")
+	newIDs := fs.Bool("new-ids", false, "用雪花算法重新生成用户、帖子、评论的ID，并改写引用它们的字段")
+	idMap := fs.String("id-map", "", "旧ID到新ID的映射文件，分多次导入时用来改写引用的ID")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	kinds, err := dataKinds(*kind)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	m, err := logic.LoadIDMap(*idMap)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !*dryRun {
+		if err = initData(configFileName, true, *newIDs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer mysql.Close()
+		defer redis.Close()
+		if *newIDs {
+			// 释放租约要用到redis，所以先于redis关闭
+			defer snowflake.Close()
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	code := 0
+	for _, k := range kinds {
+		path := dataPath(*in, k, *format, len(kinds) > 1)
+		if len(kinds) > 1 {
+			if _, err = os.Stat(path); os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "skip %s: %s not found\n", k, path)
+				continue
+			}
+		}
+
+		report, err := importOne(ctx, path, *format, logic.ImportOptions{
+			Kind:      k,
+			BatchSize: *batch,
+			DryRun:    *dryRun,
+			NewIDs:    *newIDs,
+			IDMap:     m,
+		})
+		if report != nil {
+			printReport(path, report, *dryRun)
+			if report.Invalid > 0 {
+				code = 1
+			}
+		}
+		// 已经提交的批次用的是新ID，失败了也要把映射保存下来，方便排查和续导；回滚的批次不会留在映射中
+		if *newIDs && !*dryRun && *idMap != "" {
+			if serr := m.Save(*idMap); serr != nil {
+				fmt.Fprintf(os.Stderr, "save id map failed: %v\n", serr)
+				code = 1
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import %s failed: %v\n", k, err)
+			return 1
+		}
+	}
+	return code
+}
+
+func importOne(ctx context.Context, path, format string, opts logic.ImportOptions) (*logic.ImportReport, error) {
+	var f io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		f = file
+	}
+
+	r, err := dataio.NewReader(format, f)
+	if err != nil {
+		return nil, err
+	}
+	return logic.Import(ctx, r, opts)
+}
+
+func printReport(path string, report *logic.ImportReport, dryRun bool) {
+	for _, e := range report.Errors {
+		fmt.Fprintf(os.Stderr, "%s:%d: %s\n", path, e.Line, e.Msg)
+	}
+	verb := "imported"
+	if dryRun {
+		verb = "valid"
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d rows, %d %s, %d invalid\n",
+		report.Kind, report.Total, report.Imported, verb, report.Invalid)
+}
+
+// dataKinds 解析 -type 参数
+func dataKinds(kind string) ([]string, error) {
+	if kind == "all" {
+		return logic.DataKinds, nil
+	}
+	for _, k := range logic.DataKinds {
+		if k == kind {
+			return []string{k}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown -type %q, must be all or one of %v", kind, logic.DataKinds)
+}
+
+// dataPath 单类数据时 path 就是文件（默认"-"）；全部数据时 path 是目录，文件名为 <type>.<format>
+func dataPath(path, kind, format string, dir bool) string {
+	if !dir {
+		if path == "" {
+			return "-"
+		}
+		return path
+	}
+	if path == "" {
+		path = "."
+	}
+	return filepath.Join(path, kind+"."+format)
+}
+
+// initData 导出只需要配置和MySQL；导入时还要连接redis清理缓存，生成新ID时还要初始化雪花算法
+func initData(configFileName string, importing, withSnowflake bool) error {
+	if err := settings.Init(configFileName); err != nil {
+		return fmt.Errorf("init settings failed, err:%v", err)
+	}
+	conf := settings.Get()
+	if err := mysql.Init(conf.MySQLConfig); err != nil {
+		return fmt.Errorf("init mysql failed, err:%v", err)
+	}
+	if !importing {
+		return nil
+	}
+	// 导入的数据可能被负缓存过，入库后要删掉；租用机器ID也需要redis
+	if err := redis.Init(conf.RedisConfig); err != nil {
+		mysql.Close()
+		return fmt.Errorf("init redis failed, err:%v", err)
+	}
+	if !withSnowflake {
+		return nil
+	}
+	if err := initSnowflake(conf); err != nil {
+		redis.Close()
+		mysql.Close()
+		return fmt.Errorf("init snowflake failed, err:%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"forumProject/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 审计记录默认和最多返回的条数
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// audit 写一条审计记录，reason为空表示成功
+func audit(c *gin.Context, event, actor, target, reason string) {
+	outcome := logger.OutcomeSuccess
+	if reason != "" {
+		outcome = logger.OutcomeFailure
+	}
+	logger.Audit(c.Request.Context(), logger.AuditEvent{
+		Event:     event,
+		Actor:     actor,
+		Target:    target,
+		Outcome:   outcome,
+		Reason:    reason,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// AuditHandler 查询审计记录，支持 event、actor、target、outcome、ip、since、until（RFC3339）和 limit 过滤
+func AuditHandler(c *gin.Context) {
+	f := logger.AuditFilter{
+		Event:   c.Query("event"),
+		Actor:   c.Query("actor"),
+		Target:  c.Query("target"),
+		Outcome: c.Query("outcome"),
+		IP:      c.Query("ip"),
+	}
+	var err error
+	if f.Limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditLimit))); err != nil || f.Limit <= 0 || f.Limit > maxAuditLimit {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "limit必须是1到" + strconv.Itoa(maxAuditLimit) + "之间的整数",
+		})
+		return
+	}
+	for key, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		v := c.Query(key)
+		if v == "" {
+			continue
+		}
+		if *t, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"msg": key + "必须是RFC3339格式的时间，如2023-01-11T19:10:00+08:00",
+			})
+			return
+		}
+	}
+
+	events, err := logger.QueryAudit(f)
+	if err != nil {
+		logger.WithContext(c.Request.Context()).Error("logger.QueryAudit failed", zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "查询审计记录失败",
+		})
+		return
+	}
+	if events == nil {
+		events = []logger.AuditEvent{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": events,
+	})
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"forumProject/logger"
+	"forumProject/models"
+	"forumProject/settings"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevelHandler 当前生效的日志级别和临时级别
+func LogLevelHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": logger.Levels(),
+	})
+}
+
+// SetLogLevelHandler 临时修改全局或某个包的日志级别，可以指定多少秒后自动恢复
+func SetLogLevelHandler(c *gin.Context) {
+	p := new(models.ParamLogLevel)
+	if err := c.ShouldBindJSON(p); err != nil {
+		invalidParam(c, err)
+		return
+	}
+	// 参数校验已经限制了取值，这里不会出错
+	level, _ := zapcore.ParseLevel(p.Level)
+	logger.SetLevel(p.Package, level, time.Duration(p.TTL)*time.Second)
+	logger.WithContext(c.Request.Context()).Warn("log level overridden",
+		zap.String("package", p.Package), zap.String("level", p.Level), zap.Int("ttl", p.TTL))
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": logger.Levels(),
+	})
+}
+
+// ResetLogLevelHandler 撤销临时级别，?package= 为空时撤销全局级别
+func ResetLogLevelHandler(c *gin.Context) {
+	pkg := c.Query("package")
+	logger.ResetLevel(pkg)
+	logger.WithContext(c.Request.Context()).Warn("log level override removed", zap.String("package", pkg))
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": logger.Levels(),
+	})
+}
+
+// LogCaptureHandler 当前的请求体记录设置
+func LogCaptureHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": logger.Capture(),
+	})
+}
+
+// SetLogCaptureHandler 运行时开关请求体记录，无需重启
+func SetLogCaptureHandler(c *gin.Context) {
+	p := new(models.ParamLogCapture)
+	if err := c.ShouldBindJSON(p); err != nil {
+		invalidParam(c, err)
+		return
+	}
+	logger.SetCapture(&settings.LogCaptureConfig{
+		Enabled:       p.Enabled,
+		Routes:        p.Routes,
+		SamplePercent: p.SamplePercent,
+		MaxBody:       p.MaxBody,
+	})
+	logger.WithContext(c.Request.Context()).Warn("body capture changed", zap.Any("capture", logger.Capture()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": logger.Capture(),
+	})
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单个参数的错误
+type FieldError struct {
+	Field   string `json:"field"`            // JSON 字段路径，如 re_password、routes[0]
+	Rule    string `json:"rule"`             // 没有通过的规则，如 required、max；请求体解析失败时是 json、type
+	Param   string `json:"param,omitempty"`  // 规则的参数，如 max=10 中的 10
+	Message string `json:"message"`          // 按 InitTrans 的语言翻译好的提示
+	Offset  int64  `json:"offset,omitempty"` // 请求体解析失败的字节位置
+}
+
+// invalidParam 参数错误的响应，errors 中按字段列出每个错误
+func invalidParam(c *gin.Context, err error) {
+	c.JSON(http.StatusOK, gin.H{
+		"msg":    "请求参数错误",
+		"errors": fieldErrors(err, c.Request.ContentLength),
+	})
+}
+
+// fieldErrors 把 ShouldBind 返回的错误统一转换成 FieldError，size 是请求体长度
+func fieldErrors(err error, size int64) []FieldError {
+	var (
+		errs      validator.ValidationErrors
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		numErr    *strconv.NumError
+	)
+	switch {
+	case errors.As(err, &errs):
+		res := make([]FieldError, 0, len(errs))
+		seen := make(map[string]bool, len(errs))
+		for _, e := range errs {
+			field := e.Namespace()
+			field = field[strings.Index(field, ".")+1:]
+			// 字段和结构体级别的校验可能报同一个字段，只保留第一个
+			if seen[field] {
+				continue
+			}
+			seen[field] = true
+			res = append(res, FieldError{
+				Field:   field,
+				Rule:    e.Tag(),
+				Param:   e.Param(),
+				Message: e.Translate(trans),
+			})
+		}
+		return res
+	case errors.As(err, &syntaxErr):
+		return []FieldError{{Rule: "json", Offset: syntaxErr.Offset, Message: message("json")}}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// JSON 没写完就结束了，位置就是请求体末尾
+		return []FieldError{{Rule: "json", Offset: size, Message: message("json")}}
+	case errors.Is(err, io.EOF):
+		return []FieldError{{Rule: "body", Message: message("body")}}
+	case errors.As(err, &typeErr):
+		kind := jsonKind(typeErr.Type)
+		if typeErr.Field == "" {
+			return []FieldError{{Rule: "type", Param: kind, Offset: typeErr.Offset, Message: message("body_type", kind)}}
+		}
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   kind,
+			Offset:  typeErr.Offset,
+			Message: message("type", typeErr.Field, kind),
+		}}
+	case errors.As(err, &numErr):
+		// query 和 form 参数转数字失败，gin 不会带上字段名
+		return []FieldError{{Rule: "type", Param: "number", Message: message("number", numErr.Num)}}
+	}
+	return []FieldError{{Rule: "invalid", Message: message("invalid")}}
+}
+
+// message 翻译 messages 中的提示，翻译器没有初始化时返回规则名
+func message(key string, params ...string) string {
+	if trans == nil {
+		return key
+	}
+	text, err := trans.T(key, params...)
+	if err != nil {
+		return key
+	}
+	return text
+}
+
+// jsonKind Go 类型对应的 JSON 类型名
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonKind(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return t.String()
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+	"forumProject/dao/mysql"
+	"forumProject/logger"
+	"forumProject/logic"
+	"forumProject/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PostDetailHandler 帖子详情
+func PostDetailHandler(c *gin.Context) {
+
+	// 1. 获取参数
+	p := new(models.ParamPostDetail)
+	if err := c.ShouldBindUri(p); err != nil {
+		invalidParam(c, err)
+		return
+	}
+	postID := p.PostID
+
+	// 2. 业务逻辑
+	detail, err := logic.GetPostDetail(c.Request.Context(), postID)
+	if errors.Is(err, mysql.ErrorPostNotExist) {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "帖子不存在",
+		})
+		return
+	}
+	if err != nil {
+		logger.WithContext(c.Request.Context()).Error("logic.GetPostDetail failed", zap.Uint64("post_id", postID), zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "服务繁忙",
+		})
+		return
+	}
+
+	// 3. 返回值
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": detail,
+	})
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	snowflake "forumProject/pkg/sonwflake"
+	"forumProject/settings"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// rules 论坛参数的自定义校验规则，InitTrans 时注册到gin的校验器，提示在 messages 中
+var rules = map[string]validator.Func{
+	"username":  validateUsername,
+	"password":  validatePassword,
+	"snowflake": validateSnowflake,
+}
+
+// translators 提示需要规则参数以外信息的规则，其他规则的提示参数是字段名和规则参数
+var translators = map[string]validator.TranslationFunc{
+	"password": translatePassword,
+}
+
+// 用户名的长度，按字符计算
+const (
+	usernameMinLen = 3
+	usernameMaxLen = 20
+)
+
+// registerRules 注册 rules 中的校验规则
+func registerRules(v *validator.Validate) error {
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateUsername 字母、数字、下划线和汉字，长度在 usernameMinLen 到 usernameMaxLen 之间
+func validateUsername(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	n := utf8.RuneCountInString(name)
+	if n < usernameMinLen || n > usernameMaxLen {
+		return false
+	}
+	for _, r := range name {
+		if r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))) || unicode.Is(unicode.Han, r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// passwordPolicy 当前的密码强度要求，没有配置 password_policy 时使用默认值
+func passwordPolicy() settings.PasswordPolicyConfig {
+	if conf := settings.Get(); conf != nil && conf.PasswordPolicyConfig != nil {
+		return *conf.PasswordPolicyConfig
+	}
+	return settings.PasswordPolicyConfig{MinLength: 8, MaxLength: 64, MinClasses: 2}
+}
+
+// passwordClasses 密码中包含大写字母、小写字母、数字、符号中的几类
+func passwordClasses(password string) int {
+	var upper, lower, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		case !unicode.IsSpace(r):
+			symbol = 1
+		}
+	}
+	return upper + lower + digit + symbol
+}
+
+// checkPassword 返回密码没有满足的那一项要求在 messages 中的提示，满足时返回空字符串
+// 长度按字符计算；MaxLength 为0时不限制最大长度
+func checkPassword(p settings.PasswordPolicyConfig, password string) string {
+	n := utf8.RuneCountInString(password)
+	switch {
+	case p.MaxLength > 0 && (n < p.MinLength || n > p.MaxLength):
+		return "password_length"
+	case n < p.MinLength:
+		return "password_min_length"
+	case passwordClasses(password) < p.MinClasses:
+		return "password_classes"
+	}
+	return ""
+}
+
+// validatePassword 按配置的 password_policy 校验密码强度
+func validatePassword(fl validator.FieldLevel) bool {
+	return checkPassword(passwordPolicy(), fl.Field().String()) == ""
+}
+
+// translatePassword 只提示没有满足的那一项要求
+func translatePassword(ut ut.Translator, fe validator.FieldError) string {
+	p := passwordPolicy()
+	password, _ := fe.Value().(string)
+	var t string
+	switch key := checkPassword(p, password); key {
+	case "password_length":
+		t, _ = ut.T(key, fe.Field(), strconv.Itoa(p.MinLength), strconv.Itoa(p.MaxLength))
+	case "password_min_length":
+		t, _ = ut.T(key, fe.Field(), strconv.Itoa(p.MinLength))
+	case "password_classes":
+		t, _ = ut.T(key, fe.Field(), strconv.Itoa(p.MinClasses))
+	default:
+		// 校验之后策略被热加载修改了
+		t, _ = ut.T("password", fe.Field())
+	}
+	return t
+}
+
+// fieldID 取出整数或十进制字符串形式的ID，其他类型返回false
+func fieldID(field reflect.Value) (uint64, bool) {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field.Uint(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Int() < 0 {
+			return 0, false
+		}
+		return uint64(field.Int()), true
+	case reflect.String:
+		id, err := strconv.ParseUint(field.String(), 10, 64)
+		return id, err == nil
+	}
+	return 0, false
+}
+
+// validateSnowflake 雪花算法生成的ID，解析出的生成时间不能晚于当前时间
+func validateSnowflake(fl validator.FieldLevel) bool {
+	id, ok := fieldID(fl.Field())
+	if !ok || id == 0 {
+		return false
+	}
+	// 留一分钟给实例之间的时钟误差
+	return !snowflake.Decode(id).Time.After(time.Now().Add(time.Minute))
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+	"forumProject/settings"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func newTestValidator(t *testing.T) *validator.Validate {
+	t.Helper()
+	v := validator.New()
+	if err := registerRules(v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestUsernameRule(t *testing.T) {
+	v := newTestValidator(t)
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"abc", true},
+		{"user_01", true},
+		{"张三丰", true},
+		{"用户_a1", true},
+		{strings.Repeat("a", usernameMaxLen), true},
+		{strings.Repeat("汉", usernameMaxLen), true}, // 按字符计算，不是按字节
+		{"ab", false},
+		{"张三", false},
+		{strings.Repeat("a", usernameMaxLen+1), false},
+		{"user name", false},
+		{"user-name", false},
+		{"<script>", false},
+		{"ｕｓｅｒ", false}, // 全角字母
+		{"ユーザー", false}, // 汉字以外的文字
+		{"ＡＢ１２", false}, // 全角字母和数字
+	}
+	for _, tt := range tests {
+		err := v.Var(tt.name, "username")
+		if (err == nil) != tt.ok {
+			t.Errorf("username %q: err = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	policy := func(min, max, classes int) settings.PasswordPolicyConfig {
+		return settings.PasswordPolicyConfig{MinLength: min, MaxLength: max, MinClasses: classes}
+	}
+	tests := []struct {
+		name     string
+		policy   settings.PasswordPolicyConfig
+		password string
+		want     string
+	}{
+		{"default ok", policy(8, 64, 2), "abcdefg1", ""},
+		{"too short", policy(8, 64, 2), "abc1", "password_length"},
+		{"too long", policy(8, 10, 2), "abcdefghij1", "password_length"},
+		{"one class", policy(8, 64, 2), "abcdefgh", "password_classes"},
+		// MaxLength 为0时不限制最大长度，提示里也不能出现最大长度
+		{"no max ok", policy(8, 0, 2), strings.Repeat("a1", 500), ""},
+		{"no max too short", policy(8, 0, 2), "a1", "password_min_length"},
+		// MinClasses 为0时只检查长度
+		{"no classes", policy(4, 0, 0), "    ", ""},
+		{"no classes too short", policy(4, 0, 0), "aaa", "password_min_length"},
+		// MinClasses 为4时四类都要有
+		{"all classes ok", policy(8, 64, 4), "Abcdef1!", ""},
+		{"all classes missing symbol", policy(8, 64, 4), "Abcdefg1", "password_classes"},
+		// 非ASCII：按字符计算长度，带大小写的字母算字母，汉字算符号，全角数字算数字
+		{"non-ascii length", policy(8, 8, 1), "密码密码密码密码", ""},
+		{"non-ascii too long", policy(8, 8, 1), "密码密码密码密码密", "password_length"},
+		{"accented letters", policy(4, 64, 2), "Éclair", ""},
+		{"han counts as symbol", policy(4, 64, 2), "abcd密", ""},
+		{"fullwidth digit", policy(4, 64, 4), "Ab!１", ""},
+		{"spaces are no class", policy(4, 64, 2), "abc   ", "password_classes"},
+	}
+	for _, tt := range tests {
+		if got := checkPassword(tt.policy, tt.password); got != tt.want {
+			t.Errorf("%s: checkPassword(%q) = %q, want %q", tt.name, tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestSnowflakeRule(t *testing.T) {
+	v := newTestValidator(t)
+	tests := []struct {
+		value interface{}
+		ok    bool
+	}{
+		{uint64(1 << 30), true},
+		{int64(12345), true},
+		{"1234567890", true},
+		{uint64(0), false},
+		{int64(-1), false},
+		{"", false},
+		{"abc", false},
+		{"-1", false},
+		{"18446744073709551616", false}, // 超出uint64
+		{1.5, false},
+	}
+	for _, tt := range tests {
+		err := v.Var(tt.value, "snowflake")
+		if (err == nil) != tt.ok {
+			t.Errorf("snowflake %#v: err = %v, want ok = %v", tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestUsernameMessage(t *testing.T) {
+	for _, locale := range []string{"zh", "en"} {
+		if err := InitTrans(locale); err != nil {
+			t.Fatalf("InitTrans(%s): %v", locale, err)
+		}
+		err := binding.Validator.Engine().(*validator.Validate).Var("ab", "username")
+		var errs validator.ValidationErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("err = %v, want ValidationErrors", err)
+		}
+		// 提示中的长度来自 usernameMinLen 和 usernameMaxLen
+		msg := errs[0].Translate(trans)
+		for _, n := range []int{usernameMinLen, usernameMaxLen} {
+			if !strings.Contains(msg, strconv.Itoa(n)) {
+				t.Errorf("%s: username message %q should mention %d", locale, msg, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"errors"
+	"forumProject/logger"
+	"forumProject/pkg/scheduler"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 执行记录默认和最多返回的条数
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// TaskListHandler 所有定时任务的状态，以及当前实例是否是leader
+func TaskListHandler(c *gin.Context) {
+	tasks, err := scheduler.Tasks(c.Request.Context())
+	if err != nil {
+		logger.WithContext(c.Request.Context()).Error("scheduler.Tasks failed", zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "获取定时任务失败",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"msg": "success",
+		"data": gin.H{
+			"leader": scheduler.IsLeader(),
+			"tasks":  tasks,
+		},
+	})
+}
+
+// TaskHistoryHandler 定时任务最近的执行记录，?limit= 指定条数
+func TaskHistoryHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if err != nil || limit <= 0 || limit > maxHistoryLimit {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "limit必须是1到" + strconv.Itoa(maxHistoryLimit) + "之间的整数",
+		})
+		return
+	}
+	runs, err := scheduler.History(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrUnknownTask) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"msg": "定时任务不存在",
+			})
+			return
+		}
+		logger.WithContext(c.Request.Context()).Error("scheduler.History failed", zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "获取执行记录失败",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": runs,
+	})
+}
+
+// TaskRunHandler 手动执行一次定时任务，由leader在下一秒内执行，结果见执行记录
+func TaskRunHandler(c *gin.Context) {
+	name := c.Param("name")
+	if err := scheduler.Trigger(c.Request.Context(), name); err != nil {
+		if errors.Is(err, scheduler.ErrUnknownTask) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"msg": "定时任务不存在",
+			})
+			return
+		}
+		logger.WithContext(c.Request.Context()).Error("scheduler.Trigger failed", zap.String("task", name), zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "提交失败，请稍后重试",
+		})
+		return
+	}
+	logger.WithContext(c.Request.Context()).Info("scheduled task triggered manually", zap.String("task", name))
+	c.JSON(http.StatusAccepted, gin.H{
+		"msg": "已提交，由leader执行",
+	})
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"forumProject/logger"
+	snowflake "forumProject/pkg/sonwflake"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SnowflakeHandler 生成一个ID
+func SnowflakeHandler(c *gin.Context) {
+	id, err := snowflake.GetID()
+	if err != nil {
+		// 生成失败只影响本次请求，不能让整个服务退出
+		logger.WithContext(c.Request.Context()).Error("snowflake.GetID failed", zap.Error(err))
+		c.String(http.StatusInternalServerError, "生成ID失败")
+		return
+	}
+	c.String(http.StatusOK, strconv.FormatUint(id, 10))
+}
+
+// SnowflakeDecodeHandler 解析ID的生成时间、机器ID和序列号
+// id 默认是十进制，也可以通过 ?format=base62 或 ?format=base58 传入编码后的ID
+func SnowflakeDecodeHandler(c *gin.Context) {
+	raw := c.Param("id")
+
+	var (
+		id  uint64
+		err error
+	)
+	switch c.DefaultQuery("format", "dec") {
+	case "dec":
+		id, err = strconv.ParseUint(raw, 10, 64)
+	case "base62":
+		id, err = snowflake.DecodeBase62(raw)
+	case "base58":
+		id, err = snowflake.DecodeBase58(raw)
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "format只能是dec、base62或base58",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "无效的ID",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": snowflake.Decode(id),
+	})
+}
+
+// SnowflakeStatsHandler ID生成的统计数据
+func SnowflakeStatsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": snowflake.Stats(),
+	})
+}
@@ -1,12 +1,13 @@
 package controller
 
 import (
+	"errors"
+	"forumProject/dao/mysql"
+	"forumProject/logger"
 	"forumProject/logic"
 	"forumProject/models"
 	"net/http"
 
-	"github.com/go-playground/validator/v10"
-
 	"go.uber.org/zap"
 
 	"github.com/gin-gonic/gin"
@@ -17,31 +18,28 @@ func SignUpHandler(c *gin.Context) {
 	// 1. 获取参数和参数校验
 	p := new(models.ParamSignUp)
 	if err := c.ShouldBindJSON(p); err != nil {
-		zap.L().Error("SignUp with invalid param", zap.Error(err))
+		logger.WithContext(c.Request.Context()).Error("SignUp with invalid param", zap.Error(err))
 
-		// 判断err类型是否是validator内置的类型
-		errs, ok := err.(validator.ValidationErrors)
-		if !ok {
-			c.JSON(http.StatusOK, gin.H{
-				"msg": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"msg": errs.Translate(trans), // 使用翻译器
-		})
+		invalidParam(c, err)
 		return
 	}
 
 	// 2. 业务逻辑
-	if err := logic.SignUp(p); err != nil {
-		zap.L().Error("logic.SignUp failed", zap.String("username", p.Username), zap.Error(err))
+	if err := logic.SignUp(c.Request.Context(), p); err != nil {
+		logger.WithContext(c.Request.Context()).Error("logic.SignUp failed", zap.String("username", p.Username), zap.Error(err))
+		msg, reason := "注册失败，请稍后重试", "internal error"
+		if errors.Is(err, mysql.ErrorUserExist) {
+			msg, reason = "用户名已被注册", "user exists"
+		}
+		audit(c, logger.AuditSignUp, p.Username, p.Username, reason)
 		c.JSON(http.StatusOK, gin.H{
-			"msg": "用户名已被注册",
+			"msg": msg,
 		})
 		return
 	}
 
+	audit(c, logger.AuditSignUp, p.Username, p.Username, "")
+
 	// 3. 返回值
 	c.JSON(http.StatusOK, gin.H{
 		"msg": "SignUpHandler successed",
@@ -52,31 +50,31 @@ func LoginHandler(c *gin.Context) {
 	// 1. 获取参数和参数校验
 	p := new(models.ParamLogin)
 	if err := c.ShouldBindJSON(p); err != nil {
-		zap.L().Error("Login with invalid param", zap.Error(err))
+		logger.WithContext(c.Request.Context()).Error("Login with invalid param", zap.Error(err))
 
-		// 判断err类型是否是validator内置的类型
-		errs, ok := err.(validator.ValidationErrors)
-		if !ok {
-			c.JSON(http.StatusOK, gin.H{
-				"msg": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"msg": errs.Translate(trans), // 使用翻译器
-		})
+		invalidParam(c, err)
 		return
 	}
 
 	// 2.业务逻辑
-	if err := logic.Login(p); err != nil {
-		zap.L().Error("logic.Login failed", zap.String("username", p.Username), zap.Error(err))
+	if err := logic.Login(c.Request.Context(), p); err != nil {
+		logger.WithContext(c.Request.Context()).Error("logic.Login failed", zap.String("username", p.Username), zap.Error(err))
+		reason := "internal error"
+		switch {
+		case errors.Is(err, mysql.ErrorUserNotExist):
+			reason = "user not exist"
+		case errors.Is(err, mysql.ErrorInvalidPassword):
+			reason = "invalid password"
+		}
+		audit(c, logger.AuditLoginFailure, p.Username, p.Username, reason)
 		c.JSON(http.StatusOK, gin.H{
 			"msg": "用户名或密码错误",
 		})
 		return
 	}
 
+	audit(c, logger.AuditLoginSuccess, p.Username, p.Username, "")
+
 	// 3.返回响应
 	c.JSON(http.StatusOK, gin.H{
 		"msg": "LoginHandler successed",
@@ -35,6 +35,11 @@ func InitTrans(locale string) (err error) {
 		// 为SignUpParam注册自定义校验方法
 		v.RegisterStructValidation(SignUpParamStructLevelValidation, models.ParamSignUp{})
 
+		// 注册用户名、密码强度等自定义规则
+		if err = registerRules(v); err != nil {
+			return err
+		}
+
 		zhT := zh.New() // 中文翻译器
 		enT := en.New() // 英文翻译器
 
@@ -60,12 +65,66 @@ func InitTrans(locale string) (err error) {
 		default:
 			err = enTranslations.RegisterDefaultTranslations(v, trans)
 		}
-		return
+		if err != nil {
+			return
+		}
+		return registerMessages(v, locale)
 	}
 	return
 }
 
-//定义一个去掉结构体名称前缀的自定义方法：
+// messages 自定义规则和请求体解析错误的提示，按语言注册到翻译器
+// 结构体级别校验用 sl.ReportError 上报这里的规则名，就能带上对应的提示；{0} 是字段名，{1} 是规则参数
+var messages = map[string]map[string]string{
+	"password_mismatch": {"zh": "两次输入的密码不一致", "en": "passwords do not match"},
+	"username": {
+		"zh": fmt.Sprintf("{0}只能包含字母、数字、下划线和汉字，长度为%d到%d个字符", usernameMinLen, usernameMaxLen),
+		"en": fmt.Sprintf("{0} must be %d to %d letters, digits, underscores or Chinese characters", usernameMinLen, usernameMaxLen),
+	},
+	// password 的提示由 translatePassword 按没有满足的要求选择下面三条之一
+	"password":            {"zh": "{0}强度不够", "en": "{0} is too weak"},
+	"password_length":     {"zh": "{0}长度必须在{1}到{2}个字符之间", "en": "{0} must be between {1} and {2} characters"},
+	"password_min_length": {"zh": "{0}长度不能少于{1}个字符", "en": "{0} must be at least {1} characters"},
+	"password_classes": {
+		"zh": "{0}必须包含大写字母、小写字母、数字、符号中的至少{1}类",
+		"en": "{0} must contain at least {1} of uppercase letters, lowercase letters, digits and symbols",
+	},
+	"snowflake": {"zh": "{0}不是合法的ID", "en": "{0} is not a valid ID"},
+	"json":      {"zh": "请求体不是合法的JSON", "en": "request body is not valid JSON"},
+	"type":      {"zh": "{0}必须是{1}", "en": "{0} must be {1}"},
+	"body_type": {"zh": "请求体必须是{0}", "en": "request body must be {0}"},
+	"body":      {"zh": "请求体不能为空", "en": "request body is required"},
+	"number":    {"zh": "{0}不是合法的数字", "en": "{0} is not a valid number"},
+	"invalid":   {"zh": "参数格式错误", "en": "invalid parameter"},
+}
+
+// registerMessages 把 messages 注册成 validator 的翻译，没有对应语言时用英文
+// 提示中的 {n} 必须从 {0} 开始连续编号，并按编号顺序出现
+func registerMessages(v *validator.Validate, locale string) error {
+	for tag, texts := range messages {
+		text, ok := texts[locale]
+		if !ok {
+			text = texts["en"]
+		}
+		tag := tag
+		translate, ok := translators[tag]
+		if !ok {
+			translate = func(ut ut.Translator, fe validator.FieldError) string {
+				t, _ := ut.T(fe.Tag(), fe.Field(), fe.Param())
+				return t
+			}
+		}
+		err := v.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+			return ut.Add(tag, text, true)
+		}, translate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 定义一个去掉结构体名称前缀的自定义方法：
 func removeTopStruct(fields map[string]string) map[string]string {
 	res := map[string]string{}
 	for field, err := range fields {
@@ -79,7 +138,7 @@ func SignUpParamStructLevelValidation(sl validator.StructLevel) {
 	user := sl.Current().Interface().(models.ParamSignUp)
 
 	if user.Password != user.RePassword {
-		// 输出错误提示信息，最后一个参数就是传递的param
-		sl.ReportError(user.RePassword, "re_password", "RePassword", "eqfield", "password")
+		// 输出错误提示信息，规则名对应 messages 中的提示，最后一个参数就是传递的param
+		sl.ReportError(user.RePassword, "re_password", "RePassword", "password_mismatch", "password")
 	}
 }
@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"context"
+	"forumProject/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DeletedCommentIDs 在before之前删除、并且没有回复的评论，最多limit条
+// 删除时会修改status，update_time 就是删除的时间
+func DeletedCommentIDs(ctx context.Context, q Querier, before time.Time, limit int) ([]uint64, error) {
+	sqlStr := `select c.comment_id from comment c
+		where c.status = ? and c.update_time < ?
+		and not exists (select 1 from comment r where r.parent_id = c.comment_id)
+		order by c.id limit ?`
+	var ids []uint64
+	err := q.SelectContext(ctx, &ids, sqlStr, models.CommentStatusDeleted, before, limit)
+	return ids, err
+}
+
+// PurgeComments 物理删除评论，返回删除的条数
+// 只删除仍然是已删除状态、并且在查询之后没有新回复的评论
+func PurgeComments(ctx context.Context, q Querier, ids []uint64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	// 子查询包一层派生表，MySQL不允许在delete的子查询中直接读同一张表
+	sqlStr, args, err := sqlx.In(`delete from comment
+		where comment_id in (?) and status = ?
+		and comment_id not in (select parent_id from (select parent_id from comment where parent_id in (?)) t)`,
+		ids, models.CommentStatusDeleted, ids)
+	if err != nil {
+		return 0, err
+	}
+	res, err := q.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"context"
+	"forumProject/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 导出时逐行扫描，不会把整张表读进内存；配置了从库时从从库读
+
+func ExportUsers(ctx context.Context, fn func(*models.User) error) error {
+	sqlStr := `select user_id, username, password, coalesce(email, '') as email, gender,
+		coalesce(create_time, now()) as create_time from user order by id`
+	return export(ctx, sqlStr, func(rows *sqlx.Rows) error {
+		u := new(models.User)
+		if err := rows.StructScan(u); err != nil {
+			return err
+		}
+		return fn(u)
+	})
+}
+
+func ExportCommunities(ctx context.Context, fn func(*models.Community) error) error {
+	sqlStr := `select community_id, community_name, introduction, create_time from community order by id`
+	return export(ctx, sqlStr, func(rows *sqlx.Rows) error {
+		c := new(models.Community)
+		if err := rows.StructScan(c); err != nil {
+			return err
+		}
+		return fn(c)
+	})
+}
+
+func ExportPosts(ctx context.Context, fn func(*models.Post) error) error {
+	sqlStr := `select post_id, title, content, author_id, community_id, status,
+		coalesce(create_time, now()) as create_time from post order by id`
+	return export(ctx, sqlStr, func(rows *sqlx.Rows) error {
+		p := new(models.Post)
+		if err := rows.StructScan(p); err != nil {
+			return err
+		}
+		return fn(p)
+	})
+}
+
+func ExportComments(ctx context.Context, fn func(*models.Comment) error) error {
+	sqlStr := `select comment_id, content, post_id, author_id, parent_id, status,
+		coalesce(create_time, now()) as create_time from comment order by id`
+	return export(ctx, sqlStr, func(rows *sqlx.Rows) error {
+		c := new(models.Comment)
+		if err := rows.StructScan(c); err != nil {
+			return err
+		}
+		return fn(c)
+	})
+}
+
+func export(ctx context.Context, sqlStr string, scan func(*sqlx.Rows) error) error {
+	rows, err := DB().QueryxContext(ctx, sqlStr)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// 导入时密码已经是加密后的值，原样写入；调用方用 WithTx 把一批数据放在一个事务里
+
+func ImportUsers(ctx context.Context, q Querier, users []*models.User) error {
+	sqlStr := `insert into user(user_id, username, password, email, gender, create_time)
+		values(:user_id, :username, :password, nullif(:email, ''), :gender, :create_time)`
+	_, err := q.NamedExecContext(ctx, sqlStr, users)
+	return err
+}
+
+func ImportCommunities(ctx context.Context, q Querier, communities []*models.Community) error {
+	sqlStr := `insert into community(community_id, community_name, introduction, create_time)
+		values(:community_id, :community_name, :introduction, :create_time)`
+	_, err := q.NamedExecContext(ctx, sqlStr, communities)
+	return err
+}
+
+func ImportPosts(ctx context.Context, q Querier, posts []*models.Post) error {
+	sqlStr := `insert into post(post_id, title, content, author_id, community_id, status, create_time)
+		values(:post_id, :title, :content, :author_id, :community_id, :status, :create_time)`
+	_, err := q.NamedExecContext(ctx, sqlStr, posts)
+	return err
+}
+
+func ImportComments(ctx context.Context, q Querier, comments []*models.Comment) error {
+	sqlStr := `insert into comment(comment_id, content, post_id, author_id, parent_id, status, create_time)
+		values(:comment_id, :content, :post_id, :author_id, :parent_id, :status, :create_time)`
+	_, err := q.NamedExecContext(ctx, sqlStr, comments)
+	return err
+}
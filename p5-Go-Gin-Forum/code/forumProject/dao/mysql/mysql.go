@@ -11,36 +11,84 @@
 package mysql
 
 import (
-	"fmt"
+	"database/sql"
 	"forumProject/settings"
+	kitmysql "kit/mysql"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.uber.org/zap"
 )
 
 var db *sqlx.DB
 
 func Init(cfg *settings.MySQLConfig) (err error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DbName,
-	)
-	// 也可以使用MustConnect连接不成功就panic
-	db, err = sqlx.Connect("mysql", dsn)
+	db, err = kitmysql.Open(kitmysql.Options{
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		User:         cfg.User,
+		Password:     cfg.Password,
+		DBName:       cfg.DbName,
+		MaxOpenConns: cfg.MaxOpenConns,
+		MaxIdleConns: cfg.MaxIdleConns,
+		Open:         openTraced,
+	})
 	if err != nil {
 		zap.L().Error("connect DB failed", zap.Error(err))
 		return
 	}
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
+
+	// 从库使用和主库相同的连接池大小
+	if err = initReplicas(cfg.Replicas, cfg.MaxOpenConns, cfg.MaxIdleConns); err != nil {
+		zap.L().Error("init mysql replicas failed", zap.Error(err))
+		return
+	}
+
+	settings.Subscribe("mysql", onConfigChange, func(old, next *settings.AppConfig) {
+		next.MySQLConfig = old.MySQLConfig
+	})
 	return
 }
 
+// openTraced 通过otelsql包装驱动，每条SQL语句都会生成一个span
+func openTraced(driverName, dsn string) (*sql.DB, error) {
+	return otelsql.Open(driverName, dsn,
+		otelsql.WithAttributes(semconv.DBSystemMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}),
+	)
+}
+
+// open 从库使用，和主库一样经过otelsql
+func open(dsn string) (*sqlx.DB, error) {
+	sqlDB, err := openTraced("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sqlx.NewDb(sqlDB, "mysql"), nil
+}
+
+// onConfigChange 配置热加载时调整连接池大小，连接参数的修改需要重启
+func onConfigChange(old, new *settings.AppConfig) error {
+	o, n := old.MySQLConfig, new.MySQLConfig
+	if o.MaxOpenConns != n.MaxOpenConns {
+		db.SetMaxOpenConns(n.MaxOpenConns)
+		for _, r := range replicas {
+			r.db.SetMaxOpenConns(n.MaxOpenConns)
+		}
+	}
+	if o.MaxIdleConns != n.MaxIdleConns {
+		db.SetMaxIdleConns(n.MaxIdleConns)
+		for _, r := range replicas {
+			r.db.SetMaxIdleConns(n.MaxIdleConns)
+		}
+	}
+	return nil
+}
+
 // 对外暴露db
 func Close() {
+	closeReplicas()
 	_ = db.Close()
 }
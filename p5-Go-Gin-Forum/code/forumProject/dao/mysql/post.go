@@ -0,0 +1,23 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"forumProject/models"
+)
+
+var ErrorPostNotExist = errors.New("帖子不存在")
+
+func GetPostByID(ctx context.Context, q Querier, postID uint64) (*models.Post, error) {
+	post := new(models.Post)
+	sqlStr := `select post_id, title, content, author_id, community_id, status, coalesce(create_time, now()) as create_time from post where post_id = ?`
+	err := q.GetContext(ctx, post, sqlStr, postID)
+	if err == sql.ErrNoRows {
+		return nil, ErrorPostNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
@@ -0,0 +1,165 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// 从库健康检查的间隔和超时
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckTimeout  = time.Second
+)
+
+type replica struct {
+	db      *sqlx.DB
+	addr    string // 只用于日志，不带密码
+	healthy atomic.Bool
+}
+
+var (
+	replicas    []*replica
+	next        atomic.Uint64
+	stopCheck   chan struct{}
+	checkerDone sync.WaitGroup
+)
+
+type primaryKey struct{}
+
+// WithPrimary 让ctx上的读请求也走主库，用于写完马上读（从库可能还没同步）
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// router 读写分离：写请求走主库，读请求轮询健康的从库，没有健康的从库时回退到主库
+// 事务由 WithTx 在主库上开启，事务内的读写都直接走 *sqlx.Tx
+type router struct{}
+
+func (router) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return reader(ctx).GetContext(ctx, dest, query, args...)
+}
+
+func (router) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return reader(ctx).SelectContext(ctx, dest, query, args...)
+}
+
+func (router) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return reader(ctx).QueryxContext(ctx, query, args...)
+}
+
+func (router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(ctx, query, args...)
+}
+
+func (router) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return db.NamedExecContext(ctx, query, arg)
+}
+
+// reader 选出执行读请求的库
+func reader(ctx context.Context) *sqlx.DB {
+	if len(replicas) == 0 || usePrimary(ctx) {
+		return db
+	}
+	start := next.Add(1)
+	for i := range replicas {
+		r := replicas[(start+uint64(i))%uint64(len(replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return db
+}
+
+// initReplicas 连接所有从库并开始健康检查，连不上的从库先标记为不健康，不影响启动
+func initReplicas(dsns []string, maxOpen, maxIdle int) error {
+	for _, dsn := range dsns {
+		cfg, err := gomysql.ParseDSN(dsn)
+		if err != nil {
+			return err
+		}
+		// 和主库保持一致
+		cfg.ParseTime = true
+		if cfg.Params == nil {
+			cfg.Params = make(map[string]string)
+		}
+		if _, ok := cfg.Params["charset"]; !ok {
+			cfg.Params["charset"] = "utf8mb4"
+		}
+
+		rdb, err := open(cfg.FormatDSN())
+		if err != nil {
+			return err
+		}
+		rdb.SetMaxOpenConns(maxOpen)
+		rdb.SetMaxIdleConns(maxIdle)
+
+		r := &replica{db: rdb, addr: cfg.Addr}
+		if r.check(); !r.healthy.Load() {
+			zap.L().Warn("mysql replica is unreachable, reads go to other replicas or primary", zap.String("addr", r.addr))
+		}
+		replicas = append(replicas, r)
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	stopCheck = make(chan struct{})
+	checkerDone.Add(1)
+	go healthCheck()
+	return nil
+}
+
+func healthCheck() {
+	defer checkerDone.Done()
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCheck:
+			return
+		case <-ticker.C:
+		}
+		for _, r := range replicas {
+			r.check()
+		}
+	}
+}
+
+// check ping一次从库，状态变化时记录日志
+func (r *replica) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	err := r.db.PingContext(ctx)
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		zap.L().Info("mysql replica is healthy", zap.String("addr", r.addr))
+	} else {
+		zap.L().Warn("mysql replica is unhealthy, reads fall back to other replicas or primary",
+			zap.String("addr", r.addr), zap.Error(err))
+	}
+}
+
+func closeReplicas() {
+	if stopCheck != nil {
+		close(stopCheck)
+		checkerDone.Wait()
+	}
+	for _, r := range replicas {
+		_ = r.db.Close()
+	}
+}
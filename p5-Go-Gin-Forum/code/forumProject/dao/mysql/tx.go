@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// MySQL错误码
+const (
+	errDupEntry     = 1062 // ER_DUP_ENTRY
+	errLockDeadlock = 1213 // ER_LOCK_DEADLOCK
+)
+
+// 死锁重试的次数和退避时间
+const (
+	txMaxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// Querier DAO函数的执行者，*sqlx.DB 和 *sqlx.Tx 都实现了它，
+// 同一个DAO函数既可以单独执行，也可以放进 WithTx 的事务里和其他操作一起提交
+type Querier interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+}
+
+var (
+	_ Querier = (*sqlx.DB)(nil)
+	_ Querier = (*sqlx.Tx)(nil)
+)
+
+// DB 不需要事务时传给DAO函数，读请求会被路由到从库，见 router
+func DB() Querier {
+	return router{}
+}
+
+// WithTx 在一个事务中执行fn，fn返回错误或panic时回滚，否则提交
+// 遇到死锁时MySQL已经回滚了整个事务，这时会重新执行fn，所以fn里不要有数据库之外的副作用
+func WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = runTx(ctx, fn)
+		if !isDeadlock(err) || attempt == txMaxAttempts {
+			return err
+		}
+		zap.L().Warn("transaction deadlock, retrying", zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+}
+
+func runTx(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(tx)
+}
+
+func isDeadlock(err error) bool {
+	var me *gomysql.MySQLError
+	return errors.As(err, &me) && me.Number == errLockDeadlock
+}
+
+// isDuplicate 是否是违反了指定唯一索引的错误，例如 Duplicate entry 'xx' for key 'idx_username'
+func isDuplicate(err error, index string) bool {
+	var me *gomysql.MySQLError
+	if !errors.As(err, &me) || me.Number != errDupEntry {
+		return false
+	}
+	// MySQL 8.0 的提示里索引名带表名前缀：for key 'user.idx_username'
+	return strings.HasSuffix(me.Message, "'"+index+"'") || strings.HasSuffix(me.Message, "."+index+"'")
+}
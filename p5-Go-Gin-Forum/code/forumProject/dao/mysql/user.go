@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"crypto/md5"
 	"database/sql"
 	"encoding/hex"
@@ -9,40 +10,50 @@ import (
 	"forumProject/settings"
 )
 
-func CheckUserExist(username string) (err error) {
+var (
+	ErrorUserExist       = errors.New("用户已存在")
+	ErrorUserNotExist    = errors.New("用户不存在")
+	ErrorInvalidPassword = errors.New("密码错误")
+)
+
+func CheckUserExist(ctx context.Context, q Querier, username string) (err error) {
 
 	sqlStr := `select count(user_id) from user where username = ?`
 	var count int
-	if err = db.Get(&count, sqlStr, username); err != nil {
+	if err = q.GetContext(ctx, &count, sqlStr, username); err != nil {
 		return err
 	}
 	if count > 0 {
-		return errors.New("用户已存在")
+		return ErrorUserExist
 	}
 	return
 }
 
-func InsertUser(user *models.User) (err error) {
+func InsertUser(ctx context.Context, q Querier, user *models.User) (err error) {
 
 	// 对密码加密
 	password := encryptPassword(user.Password)
 
 	// 插入
 	sqlStr := `insert into user(user_id,username,password) values(?,?,?)`
-	_, err = db.Exec(sqlStr, user.UserID, user.UserName, password)
+	_, err = q.ExecContext(ctx, sqlStr, user.UserID, user.UserName, password)
+	// 并发注册同一个用户名时，检查都通过了，后插入的一方会违反唯一索引
+	if isDuplicate(err, "idx_username") {
+		return ErrorUserExist
+	}
 
 	return
 }
 
-func Login(user *models.User) (err error) {
+func Login(ctx context.Context, q Querier, user *models.User) (err error) {
 
 	oldPassword := user.Password
 
 	sqlStr := `select username,password from user where username = ?`
-	err = db.Get(user, sqlStr, user.UserName)
+	err = q.GetContext(ctx, user, sqlStr, user.UserName)
 	// 一般不会判断不存在，因为不能让用户知道
 	if err == sql.ErrNoRows {
-		return errors.New("用户不存在")
+		return ErrorUserNotExist
 	}
 	if err != nil {
 		// 数据库错误
@@ -51,13 +62,27 @@ func Login(user *models.User) (err error) {
 
 	waitProvePassword := encryptPassword(oldPassword)
 	if waitProvePassword != user.Password {
-		return errors.New("密码错误")
+		return ErrorInvalidPassword
 	}
 	return
 }
 
 func encryptPassword(oldPassword string) string {
 	h := md5.New()
-	h.Write([]byte(settings.Conf.Salt))
+	h.Write([]byte(settings.Get().Salt))
 	return hex.EncodeToString(h.Sum([]byte(oldPassword)))
 }
+
+// GetUserByID 查询用户的公开信息，不包含密码
+func GetUserByID(ctx context.Context, q Querier, userID uint64) (*models.User, error) {
+	user := new(models.User)
+	sqlStr := `select user_id, username, coalesce(email, '') as email, gender, coalesce(create_time, now()) as create_time from user where user_id = ?`
+	err := q.GetContext(ctx, user, sqlStr, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrorUserNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
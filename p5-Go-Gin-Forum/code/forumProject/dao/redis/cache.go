@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheStatsInterval 缓存命中统计的日志间隔
+const cacheStatsInterval = time.Minute
+
+// negativeValue 负缓存的占位值，正常数据序列化成JSON后不可能是这个值
+const negativeValue = "-"
+
+// CacheOptions 缓存参数
+type CacheOptions struct {
+	TTL         time.Duration // 数据的过期时间
+	Jitter      time.Duration // 在TTL上随机增加 [0, Jitter)，避免同一批key同时过期
+	NegativeTTL time.Duration // 数据不存在时占位的过期时间，0表示不做负缓存
+}
+
+// Cache 旁路缓存：先读redis，未命中时回源并写回
+// 同一个key并发未命中时只回源一次；redis出错时直接回源，不影响业务
+type Cache[T any] struct {
+	name  string
+	opts  CacheOptions
+	group singleflight.Group
+	stats cacheStats
+}
+
+type cacheStats struct {
+	hits, negativeHits, misses, errors atomic.Uint64
+	// 上一次打印日志时的值
+	lastHits, lastNegativeHits, lastMisses, lastErrors uint64
+}
+
+var (
+	cachesMu sync.Mutex
+	caches   = map[string]*cacheStats{}
+)
+
+// NewCache 创建一类数据的缓存，name 用于key和统计日志，不能重复
+func NewCache[T any](name string, opts CacheOptions) *Cache[T] {
+	c := &Cache[T]{name: name, opts: opts}
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	if _, ok := caches[name]; ok {
+		panic("redis: duplicate cache name " + name)
+	}
+	caches[name] = &c.stats
+	return c
+}
+
+// Get 读取id对应的数据，未命中时调用load回源
+// load 返回 (nil, nil) 表示数据不存在，这时Get也返回 (nil, nil)，并在开启负缓存时写入占位值
+// 并发回源时多个调用方拿到的是同一个对象，不要修改它
+func (c *Cache[T]) Get(ctx context.Context, id string, load func(ctx context.Context) (*T, error)) (*T, error) {
+	key := KeyCache(c.name, id)
+
+	val, err := Client().Get(ctx, key).Result()
+	switch {
+	case err == nil && val == negativeValue:
+		c.stats.negativeHits.Add(1)
+		return nil, nil
+	case err == nil:
+		v := new(T)
+		if err = json.Unmarshal([]byte(val), v); err == nil {
+			c.stats.hits.Add(1)
+			return v, nil
+		}
+		// 结构体字段改过之后旧数据可能解析失败，当作未命中
+		zap.L().Warn("cache: decode cached value failed", zap.String("key", key), zap.Error(err))
+	case err != redis.Nil:
+		c.stats.errors.Add(1)
+		zap.L().Warn("cache: get failed, load from source", zap.String("key", key), zap.Error(err))
+	}
+	c.stats.misses.Add(1)
+
+	// 回源不跟随单个请求取消，否则第一个请求断开会让等待同一个key的请求一起失败
+	loadCtx := context.WithoutCancel(ctx)
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		v, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		c.set(loadCtx, key, v)
+		return v, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*T), nil
+}
+
+func (c *Cache[T]) set(ctx context.Context, key string, v *T) {
+	val, ttl := negativeValue, c.opts.NegativeTTL
+	if v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			zap.L().Warn("cache: encode value failed", zap.String("key", key), zap.Error(err))
+			return
+		}
+		val, ttl = string(b), c.opts.TTL
+		if c.opts.Jitter > 0 {
+			ttl += time.Duration(rand.Int63n(int64(c.opts.Jitter)))
+		}
+	}
+	if ttl <= 0 {
+		return
+	}
+	if err := Client().Set(ctx, key, val, ttl).Err(); err != nil {
+		c.stats.errors.Add(1)
+		zap.L().Warn("cache: set failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// Invalidate 删除缓存，更新或删除数据时在写库成功之后调用
+// 每类缓存的写路径见 logic 中缓存变量的注释
+func (c *Cache[T]) Invalidate(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = KeyCache(c.name, id)
+	}
+	if err := Client().Del(ctx, keys...).Err(); err != nil {
+		c.stats.errors.Add(1)
+		return err
+	}
+	return nil
+}
+
+// reportCacheStats 定期打印每类缓存在这段时间内的命中情况，没有访问的不打印
+func reportCacheStats(stop <-chan struct{}) {
+	ticker := time.NewTicker(cacheStatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		cachesMu.Lock()
+		for name, s := range caches {
+			hits, negativeHits, misses, errs := s.hits.Load(), s.negativeHits.Load(), s.misses.Load(), s.errors.Load()
+			dh, dn, dm, de := hits-s.lastHits, negativeHits-s.lastNegativeHits, misses-s.lastMisses, errs-s.lastErrors
+			s.lastHits, s.lastNegativeHits, s.lastMisses, s.lastErrors = hits, negativeHits, misses, errs
+			total := dh + dn + dm
+			if total == 0 && de == 0 {
+				continue
+			}
+			ratio := 0.0
+			if total > 0 {
+				ratio = float64(dh+dn) / float64(total)
+			}
+			zap.L().Info("cache stats",
+				zap.String("cache", name),
+				zap.Uint64("hits", dh),
+				zap.Uint64("negative_hits", dn),
+				zap.Uint64("misses", dm),
+				zap.Uint64("errors", de),
+				zap.Float64("hit_ratio", ratio),
+			)
+		}
+		cachesMu.Unlock()
+	}
+}
@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "forumProject/dao/redis"
+
+// pipelineOp pipeline 在 op_timeouts 中的名字
+const pipelineOp = "pipeline"
+
+type (
+	cancelKey    struct{}
+	noTimeoutKey struct{}
+)
+
+// withoutOpTimeout 阻塞命令（如XREADGROUP）由调用方自己设置超时，不使用 op_timeout
+func withoutOpTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noTimeoutKey{}, true)
+}
+
+// timeoutHook 给每条命令加上超时，go-redis v8 会用ctx的deadline设置连接的读写超时，
+// 所以redis卡住时请求的goroutine最多等待这么久
+type timeoutHook struct {
+	def time.Duration
+	ops map[string]time.Duration
+}
+
+func newTimeoutHook(defMs int, opsMs map[string]int) timeoutHook {
+	h := timeoutHook{def: time.Duration(defMs) * time.Millisecond, ops: make(map[string]time.Duration, len(opsMs))}
+	for name, ms := range opsMs {
+		h.ops[strings.ToLower(name)] = time.Duration(ms) * time.Millisecond
+	}
+	return h
+}
+
+func (h timeoutHook) timeout(op string) time.Duration {
+	if d, ok := h.ops[op]; ok {
+		return d
+	}
+	return h.def
+}
+
+// withTimeout cancel 放进ctx，After* 里取出来调用
+func (h timeoutHook) withTimeout(ctx context.Context, op string) context.Context {
+	d := h.timeout(op)
+	if skip, _ := ctx.Value(noTimeoutKey{}).(bool); d <= 0 || skip {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return context.WithValue(ctx, cancelKey{}, cancel)
+}
+
+func (timeoutHook) cancel(ctx context.Context) {
+	if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+func (h timeoutHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.withTimeout(ctx, cmd.Name()), nil
+}
+
+func (h timeoutHook) AfterProcess(ctx context.Context, _ redis.Cmder) error {
+	h.cancel(ctx)
+	return nil
+}
+
+func (h timeoutHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return h.withTimeout(ctx, pipelineOp), nil
+}
+
+func (h timeoutHook) AfterProcessPipeline(ctx context.Context, _ []redis.Cmder) error {
+	h.cancel(ctx)
+	return nil
+}
+
+type spanKey struct{}
+
+// tracingHook 每条命令（或一个pipeline）生成一个span
+type tracingHook struct{}
+
+func (tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return startSpan(ctx, "redis "+cmd.Name(), cmdString(cmd)), nil
+}
+
+func (tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endSpan(ctx, cmd.Err())
+	return nil
+}
+
+func (tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	parts := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		parts[i] = cmdString(cmd)
+	}
+	return startSpan(ctx, "redis pipeline", strings.Join(parts, "\n")), nil
+}
+
+func (tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if err = cmd.Err(); err != nil && err != redis.Nil {
+			break
+		}
+	}
+	endSpan(ctx, err)
+	return nil
+}
+
+func startSpan(ctx context.Context, name, statement string) context.Context {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			attribute.String("db.statement", statement),
+		),
+	)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func endSpan(ctx context.Context, err error) {
+	span, ok := ctx.Value(spanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// cmdString 只记录命令名和key，避免把value写进链路数据
+func cmdString(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) > 2 {
+		args = args[:2]
+	}
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, fmt.Sprint(arg))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"forumProject/pkg/jobs"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	jobGroup   = "workers" // 消费者组名
+	jobField   = "job"     // stream 中保存任务JSON的字段
+	deadMaxLen = 10000     // 死信队列最多保留的任务数
+)
+
+// promoteScript 把到期的延时任务移到stream，脚本内完成保证多个实例不会重复移动
+var promoteScript = redis.NewScript(`
+local jobs = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, job in ipairs(jobs) do
+	redis.call("XADD", KEYS[2], "*", ARGV[3], job)
+	redis.call("ZREM", KEYS[1], job)
+end
+return #jobs`)
+
+// JobBroker 基于redis stream的任务存储，实现 jobs.Broker
+type JobBroker struct {
+	groupReady atomic.Bool
+}
+
+var _ jobs.Broker = (*JobBroker)(nil)
+
+func (b *JobBroker) Push(ctx context.Context, job *jobs.Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return Client().XAdd(ctx, &redis.XAddArgs{
+		Stream: KeyJobStream,
+		Values: []interface{}{jobField, raw},
+	}).Err()
+}
+
+func (b *JobBroker) Schedule(ctx context.Context, job *jobs.Job, at time.Time) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return Client().ZAdd(ctx, KeyJobDelayed, &redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: raw,
+	}).Err()
+}
+
+func (b *JobBroker) PromoteDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	return promoteScript.Run(ctx, Client(), []string{KeyJobDelayed, KeyJobStream},
+		now.UnixMilli(), limit, jobField).Int()
+}
+
+func (b *JobBroker) Fetch(ctx context.Context, consumer string, minIdle, block time.Duration) (*jobs.Delivery, error) {
+	if err := b.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	// 先认领超过minIdle没有确认的任务（取走它的worker可能已经挂了）
+	pending, err := Client().XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: KeyJobStream,
+		Group:  jobGroup,
+		Idle:   minIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  1,
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		msgs, err := Client().XClaim(ctx, &redis.XClaimArgs{
+			Stream:   KeyJobStream,
+			Group:    jobGroup,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Messages: []string{pending[0].ID},
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(msgs) > 0 {
+			return b.decode(ctx, msgs[0])
+		}
+	}
+
+	// 阻塞读不受 op_timeout 限制，超时时间按block算
+	ctx, cancel := context.WithTimeout(withoutOpTimeout(ctx), block+time.Second)
+	defer cancel()
+	streams, err := Client().XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    jobGroup,
+		Consumer: consumer,
+		Streams:  []string{KeyJobStream, ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range streams {
+		if len(s.Messages) > 0 {
+			return b.decode(ctx, s.Messages[0])
+		}
+	}
+	return nil, nil
+}
+
+// decode 解析失败的消息直接放进死信队列，不然会被反复认领
+func (b *JobBroker) decode(ctx context.Context, msg redis.XMessage) (*jobs.Delivery, error) {
+	d := &jobs.Delivery{MessageID: msg.ID, Job: new(jobs.Job)}
+	raw, _ := msg.Values[jobField].(string)
+	if err := json.Unmarshal([]byte(raw), d.Job); err != nil {
+		_, derr := Client().TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.XAdd(ctx, &redis.XAddArgs{
+				Stream: KeyJobDead,
+				MaxLen: deadMaxLen,
+				Approx: true,
+				Values: msg.Values,
+			})
+			p.XAck(ctx, KeyJobStream, jobGroup, msg.ID)
+			p.XDel(ctx, KeyJobStream, msg.ID)
+			return nil
+		})
+		if derr != nil {
+			return nil, derr
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// Ack 确认后从stream中删掉，stream只保留还没处理完的任务
+func (b *JobBroker) Ack(ctx context.Context, d *jobs.Delivery) error {
+	_, err := Client().TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.XAck(ctx, KeyJobStream, jobGroup, d.MessageID)
+		p.XDel(ctx, KeyJobStream, d.MessageID)
+		return nil
+	})
+	return err
+}
+
+func (b *JobBroker) Bury(ctx context.Context, job *jobs.Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return Client().XAdd(ctx, &redis.XAddArgs{
+		Stream: KeyJobDead,
+		MaxLen: deadMaxLen,
+		Approx: true,
+		Values: []interface{}{jobField, raw},
+	}).Err()
+}
+
+// ensureGroup 第一次取任务时创建消费者组，从头开始消费，创建之前投递的任务也不会丢
+func (b *JobBroker) ensureGroup(ctx context.Context) error {
+	if b.groupReady.Load() {
+		return nil
+	}
+	err := Client().XGroupCreateMkStream(ctx, KeyJobStream, jobGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	b.groupReady.Store(true)
+	return nil
+}
@@ -0,0 +1,35 @@
+package redis
+
+import "fmt"
+
+// redis key 统一加项目前缀，方便和其他项目共用一个库
+const KeyPrefix = "forum:"
+
+// KeyMachineIDLease 雪花算法机器ID的租约，值为持有者的token
+func KeyMachineIDLease(id uint16) string {
+	return fmt.Sprintf("%ssnowflake:machine:%d", KeyPrefix, id)
+}
+
+// KeyCache 旁路缓存的数据，如 forum:cache:post:123
+func KeyCache(name, id string) string {
+	return KeyPrefix + "cache:" + name + ":" + id
+}
+
+// 后台任务队列，到期任务的搬运脚本和进入死信的pipeline同时操作多个key，用hash tag保证cluster模式下在同一个slot
+const (
+	KeyJobStream  = KeyPrefix + "{jobs}:stream"  // 待执行的任务，stream
+	KeyJobDelayed = KeyPrefix + "{jobs}:delayed" // 延时和等待重试的任务，zset，score为执行时间（毫秒）
+	KeyJobDead    = KeyPrefix + "{jobs}:dead"    // 死信队列，stream
+)
+
+// 定时任务，leader锁和fencing token在同一个脚本里操作，用hash tag保证cluster模式下在同一个slot
+const (
+	KeySchedulerLeader  = KeyPrefix + "{scheduler}:leader"  // leader锁，值为 holder|token
+	KeySchedulerFence   = KeyPrefix + "{scheduler}:fence"   // fencing token计数器，每次选出新leader加一
+	KeySchedulerTrigger = KeyPrefix + "{scheduler}:trigger" // 手动执行的请求，list，由leader取出执行
+)
+
+// KeySchedulerHistory 任务的执行记录，list，最新的在前
+func KeySchedulerHistory(task string) string {
+	return KeyPrefix + "{scheduler}:history:" + task
+}
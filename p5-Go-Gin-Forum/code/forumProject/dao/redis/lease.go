@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 只有token一致才续约/释放，避免误操作已经被别的实例抢到的租约
+var (
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// MachineIDLeaser 基于 SET NX 实现的雪花算法机器ID租约
+type MachineIDLeaser struct{}
+
+// Acquire 尝试占用机器ID，已被其他实例占用时返回false
+func (MachineIDLeaser) Acquire(ctx context.Context, id uint16, token string, ttl time.Duration) (bool, error) {
+	return Client().SetNX(ctx, KeyMachineIDLease(id), token, ttl).Result()
+}
+
+// Renew 续约，租约已过期或被其他实例占用时返回false
+func (MachineIDLeaser) Renew(ctx context.Context, id uint16, token string, ttl time.Duration) (bool, error) {
+	n, err := renewScript.Run(ctx, Client(), []string{KeyMachineIDLease(id)},
+		token, ttl.Milliseconds()).Int64()
+	return n == 1, err
+}
+
+// Release 释放租约
+func (MachineIDLeaser) Release(ctx context.Context, id uint16, token string) error {
+	return releaseScript.Run(ctx, Client(), []string{KeyMachineIDLease(id)}, token).Err()
+}
@@ -1,31 +1,99 @@
 package redis
 
 import (
-	"fmt"
 	"forumProject/settings"
+	kitredis "kit/redis"
+	"reflect"
+	"sync/atomic"
+	"time"
 
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
 )
 
-// 声明一个全局的rdb变量
-var rdb *redis.Client
+const closeDelay = 5 * time.Second
+
+// client 包一层才能用 atomic.Pointer 保存接口
+type client struct {
+	redis.UniversalClient
+}
+
+// 声明一个全局的rdb变量，配置热加载时会整体替换成新的客户端
+// 不同模式下分别是 *redis.Client（单节点、哨兵）或 *redis.ClusterClient，调用方只看到 UniversalClient
+var rdb atomic.Pointer[client]
+
+// stopStats 停止缓存统计日志
+var stopStats chan struct{}
 
 // Init 初始化连接
 func Init(cfg *settings.RedisConfig) (err error) {
-	rdb = redis.NewClient(&redis.Options{
-		Addr: fmt.Sprintf("%s:%d",
-			cfg.Host,
-			cfg.Port,
-		),
-		Password: cfg.Password, // no password set
-		DB:       cfg.DB,       // use default DB
-		PoolSize: cfg.PoolSize,
-	})
+	c, err := newClient(cfg)
+	if err != nil {
+		return
+	}
+	rdb.Store(&client{c})
+
+	stopStats = make(chan struct{})
+	go reportCacheStats(stopStats)
 
-	_, err = rdb.Ping().Result()
+	settings.Subscribe("redis", onConfigChange, func(old, next *settings.AppConfig) {
+		next.RedisConfig = old.RedisConfig
+	})
 	return
 }
 
+func newClient(cfg *settings.RedisConfig) (redis.UniversalClient, error) {
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	opts := kitredis.Options{
+		Mode:         cfg.Mode,
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		Addrs:        cfg.Addrs,
+		MasterName:   cfg.MasterName,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  ms(cfg.DialTimeout),
+		ReadTimeout:  ms(cfg.ReadTimeout),
+		WriteTimeout: ms(cfg.WriteTimeout),
+		PoolTimeout:  ms(cfg.PoolTimeout),
+		Hooks:        []redis.Hook{newTimeoutHook(cfg.OpTimeout, cfg.OpTimeouts), tracingHook{}},
+	}
+	if t := cfg.RedisTLSConfig; t != nil && t.Enabled {
+		opts.TLS = &kitredis.TLSOptions{
+			CAFile:             t.CAFile,
+			CertFile:           t.CertFile,
+			KeyFile:            t.KeyFile,
+			ServerName:         t.ServerName,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+		}
+	}
+	return kitredis.New(opts)
+}
+
+// onConfigChange go-redis的连接参数和连接池大小创建后无法修改，
+// 所以用新配置重新建一个客户端，能连通后再替换；旧客户端延迟关闭，给正在执行的命令留出时间
+func onConfigChange(old, new *settings.AppConfig) error {
+	if reflect.DeepEqual(old.RedisConfig, new.RedisConfig) {
+		return nil
+	}
+	c, err := newClient(new.RedisConfig)
+	if err != nil {
+		return err
+	}
+	oldClient := rdb.Swap(&client{c})
+	time.AfterFunc(closeDelay, func() { _ = oldClient.Close() })
+	zap.L().Info("redis client reloaded")
+	return nil
+}
+
+// Client 返回当前的客户端，命令的ctx一般直接用请求的 c.Request.Context()，
+// 请求的deadline和 op_timeout 取较早的一个，每条命令都会在ctx的链路下生成一个span
+func Client() redis.UniversalClient {
+	return rdb.Load().UniversalClient
+}
+
 func Close() {
-	_ = rdb.Close()
+	close(stopStats)
+	_ = rdb.Load().Close()
 }
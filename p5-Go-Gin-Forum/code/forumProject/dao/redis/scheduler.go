@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"forumProject/pkg/scheduler"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	// acquireLeaderScript 锁空闲时发一个新的fencing token，锁的值为 holder|token
+	acquireLeaderScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], ARGV[1] .. "|" .. token, "PX", ARGV[2])
+return token`)
+
+	// takeTriggersScript 原子地取出并清空所有请求
+	takeTriggersScript = redis.NewScript(`
+local names = redis.call("LRANGE", KEYS[1], 0, -1)
+redis.call("DEL", KEYS[1])
+return names`)
+)
+
+// SchedulerStore 定时任务的leader锁和执行记录，实现 scheduler.Store
+type SchedulerStore struct{}
+
+var _ scheduler.Store = SchedulerStore{}
+
+func leaderValue(holder string, token int64) string {
+	return holder + "|" + strconv.FormatInt(token, 10)
+}
+
+func (SchedulerStore) Acquire(ctx context.Context, holder string, ttl time.Duration) (int64, error) {
+	return acquireLeaderScript.Run(ctx, Client(), []string{KeySchedulerLeader, KeySchedulerFence},
+		holder, ttl.Milliseconds()).Int64()
+}
+
+// Renew 和 Release 复用机器ID租约的脚本，值一致才操作
+func (SchedulerStore) Renew(ctx context.Context, holder string, token int64, ttl time.Duration) (bool, error) {
+	n, err := renewScript.Run(ctx, Client(), []string{KeySchedulerLeader},
+		leaderValue(holder, token), ttl.Milliseconds()).Int64()
+	return n == 1, err
+}
+
+func (SchedulerStore) Release(ctx context.Context, holder string, token int64) error {
+	return releaseScript.Run(ctx, Client(), []string{KeySchedulerLeader}, leaderValue(holder, token)).Err()
+}
+
+func (SchedulerStore) Fence(ctx context.Context) (int64, error) {
+	n, err := Client().Get(ctx, KeySchedulerFence).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (SchedulerStore) Record(ctx context.Context, run *scheduler.Run, keep int) error {
+	raw, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	key := KeySchedulerHistory(run.Task)
+	_, err = Client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, raw)
+		pipe.LTrim(ctx, key, 0, int64(keep-1))
+		return nil
+	})
+	return err
+}
+
+func (SchedulerStore) History(ctx context.Context, task string, limit int) ([]*scheduler.Run, error) {
+	vals, err := Client().LRange(ctx, KeySchedulerHistory(task), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]*scheduler.Run, 0, len(vals))
+	for _, v := range vals {
+		run := new(scheduler.Run)
+		if err := json.Unmarshal([]byte(v), run); err != nil {
+			// 字段改过之后的旧记录，跳过
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (SchedulerStore) RequestRun(ctx context.Context, task string) error {
+	return Client().RPush(ctx, KeySchedulerTrigger, task).Err()
+}
+
+func (SchedulerStore) TakeRequests(ctx context.Context) ([]string, error) {
+	return takeTriggersScript.Run(ctx, Client(), []string{KeySchedulerTrigger}).StringSlice()
+}
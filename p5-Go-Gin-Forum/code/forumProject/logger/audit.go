@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"forumProject/settings"
+	kitlogger "kit/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 审计事件
+const (
+	AuditSignUp         = "signup"
+	AuditLoginSuccess   = "login_success"
+	AuditLoginFailure   = "login_failure"
+	AuditPasswordChange = "password_change"
+	AuditRoleChange     = "role_change"
+	AuditModeration     = "moderation"
+)
+
+// 审计结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// AuditEvent 一条审计记录
+type AuditEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Actor     string    `json:"actor"`  // 操作人，用户名或 admin
+	Target    string    `json:"target"` // 操作对象，如用户名、帖子ID
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason,omitempty"` // 失败原因，不包含敏感信息
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	TraceID   string    `json:"trace_id,omitempty"`
+}
+
+var (
+	auditLg   = zap.NewNop()
+	auditFile string // 为空表示没有开启
+)
+
+// InitAudit 创建审计日志的logger，和普通日志使用不同的core和文件，不受日志级别和采样影响
+func InitAudit(cfg *settings.AuditConfig) {
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		MessageKey:     "event",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	}
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(encoderConfig),
+		kitlogger.Writer(cfg.Filename, cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge),
+		zapcore.InfoLevel,
+	)
+	auditLg, auditFile = zap.New(core), cfg.Filename
+}
+
+// Audit 写一条审计记录，Time 和 TraceID 自动填写
+func Audit(ctx context.Context, e AuditEvent) {
+	fields := []zap.Field{
+		zap.String("actor", e.Actor),
+		zap.String("target", e.Target),
+		zap.String("outcome", e.Outcome),
+		zap.String("ip", e.IP),
+		zap.String("user_agent", e.UserAgent),
+	}
+	if e.Reason != "" {
+		fields = append(fields, zap.String("reason", e.Reason))
+	}
+	if sc := traceFields(ctx); len(sc) > 0 {
+		fields = append(fields, sc[0])
+	}
+	auditLg.Info(e.Event, fields...)
+}
+
+// AuditFilter 查询条件，零值的字段不过滤
+type AuditFilter struct {
+	Event   string
+	Actor   string
+	Target  string
+	Outcome string
+	IP      string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+func (f *AuditFilter) match(e *AuditEvent) bool {
+	return (f.Event == "" || e.Event == f.Event) &&
+		(f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Target == "" || e.Target == f.Target) &&
+		(f.Outcome == "" || e.Outcome == f.Outcome) &&
+		(f.IP == "" || e.IP == f.IP) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+// QueryAudit 按条件查询审计记录，最新的在前，最多返回 Limit 条
+// 会依次扫描当前文件和lumberjack切分出的旧文件，最后修改时间早于 Since 的旧文件直接跳过
+func QueryAudit(f AuditFilter) ([]AuditEvent, error) {
+	if auditFile == "" {
+		return nil, nil
+	}
+	files, err := auditFiles(f.Since)
+	if err != nil {
+		return nil, err
+	}
+	// 保留最后匹配的 Limit 条
+	var events []AuditEvent
+	for _, name := range files {
+		if err := scanAudit(name, func(e *AuditEvent) {
+			if !f.match(e) {
+				return
+			}
+			events = append(events, *e)
+			if f.Limit > 0 && len(events) > f.Limit {
+				events = events[1:]
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// auditFiles 按时间顺序排列的审计日志文件，旧文件名形如 audit-2006-01-02T15-04-05.000.log
+func auditFiles(since time.Time) ([]string, error) {
+	ext := filepath.Ext(auditFile)
+	prefix := strings.TrimSuffix(auditFile, ext) + "-"
+	backups, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	// 时间戳格式固定，按文件名排序就是按时间排序
+	sort.Strings(backups)
+	files := make([]string, 0, len(backups)+1)
+	for _, name := range backups {
+		if !since.IsZero() {
+			if fi, err := os.Stat(name); err == nil && fi.ModTime().Before(since) {
+				continue
+			}
+		}
+		files = append(files, name)
+	}
+	return append(files, auditFile), nil
+}
+
+func scanAudit(name string, fn func(e *AuditEvent)) error {
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e := new(AuditEvent)
+		// 写到一半的行跳过
+		if json.Unmarshal(scanner.Bytes(), e) != nil {
+			continue
+		}
+		fn(e)
+	}
+	return scanner.Err()
+}
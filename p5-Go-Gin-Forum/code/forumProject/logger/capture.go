@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"forumProject/settings"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptureStatus 当前的请求体记录设置
+type CaptureStatus struct {
+	Enabled       bool     `json:"enabled"`
+	Routes        []string `json:"routes"`
+	SamplePercent float64  `json:"sample_percent"`
+	MaxBody       int      `json:"max_body"`
+}
+
+// captureRules 替换整个对象而不是修改，请求处理中无需加锁
+type captureRules struct {
+	CaptureStatus
+	routes map[string]bool
+}
+
+var capture atomic.Pointer[captureRules]
+
+// SetCapture 修改请求体记录设置，启动、配置热加载和管理接口都会调用
+func SetCapture(cfg *settings.LogCaptureConfig) {
+	r := &captureRules{routes: map[string]bool{}}
+	if cfg != nil {
+		r.CaptureStatus = CaptureStatus{
+			Enabled:       cfg.Enabled,
+			Routes:        append([]string{}, cfg.Routes...),
+			SamplePercent: cfg.SamplePercent,
+			MaxBody:       cfg.MaxBody,
+		}
+	}
+	if r.Routes == nil {
+		r.Routes = []string{}
+	}
+	for _, route := range r.Routes {
+		r.routes[strings.TrimSpace(route)] = true
+	}
+	capture.Store(r)
+}
+
+// Capture 当前的请求体记录设置
+func Capture() CaptureStatus {
+	if r := capture.Load(); r != nil {
+		return r.CaptureStatus
+	}
+	return CaptureStatus{Routes: []string{}}
+}
+
+// captureLimit 记录这个请求的请求体和响应体时返回保存的最大字节数，不记录时返回0
+func captureLimit(c *gin.Context) int {
+	r := capture.Load()
+	if r == nil || !r.Enabled || r.MaxBody <= 0 {
+		return 0
+	}
+	route := c.FullPath()
+	if route != "" && (r.routes[route] || r.routes[c.Request.Method+" "+route]) {
+		return r.MaxBody
+	}
+	if r.SamplePercent > 0 && rand.Float64()*100 < r.SamplePercent {
+		return r.MaxBody
+	}
+	return 0
+}
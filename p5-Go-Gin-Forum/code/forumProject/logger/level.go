@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 日志级别分两层：配置文件中的级别，以及通过管理接口临时设置的级别，临时级别优先，到期后回到配置的级别
+// 全局级别保存在 atom 中，按包的级别保存在 pkgLevels 中，两者都是生效后的结果
+
+// pkgTable 按包设置的级别，替换整个表而不是修改，写日志时无需加锁
+type pkgTable struct {
+	levels map[string]zapcore.Level
+	min    zapcore.Level // 所有包级别中最低的，用于快速判断
+}
+
+var pkgLevels atomic.Pointer[pkgTable]
+
+// modulePath 包路径的模块前缀，配置中的包路径不带这个前缀
+var modulePath = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+		return info.Main.Path
+	}
+	return "forumProject"
+}()
+
+// override 管理接口设置的临时级别
+type override struct {
+	level   zapcore.Level
+	expires time.Time // 零值表示不过期
+	timer   *time.Timer
+}
+
+var (
+	levelMu   sync.Mutex
+	cfgLevel  zapcore.Level
+	cfgPkgs   map[string]zapcore.Level
+	overrides = map[string]*override{} // key为包路径，空字符串表示全局级别
+)
+
+// setConfigLevels 配置文件中的级别，启动和热加载时调用
+func setConfigLevels(level string, packages map[string]string) error {
+	base, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	pkgs := make(map[string]zapcore.Level, len(packages))
+	for pkg, l := range packages {
+		if pkgs[normalizePackage(pkg)], err = zapcore.ParseLevel(l); err != nil {
+			return fmt.Errorf("log.packages.%s: %w", pkg, err)
+		}
+	}
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	cfgLevel, cfgPkgs = base, pkgs
+	applyLevels()
+	return nil
+}
+
+// SetLevel 临时修改日志级别，pkg为空时修改全局级别；ttl大于0时到期后自动恢复为配置的级别
+func SetLevel(pkg string, level zapcore.Level, ttl time.Duration) {
+	pkg = normalizePackage(pkg)
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	if o, ok := overrides[pkg]; ok && o.timer != nil {
+		o.timer.Stop()
+	}
+	o := &override{level: level}
+	if ttl > 0 {
+		o.expires = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() { revert(pkg, o) })
+	}
+	overrides[pkg] = o
+	applyLevels()
+}
+
+// ResetLevel 撤销临时级别，恢复为配置的级别
+func ResetLevel(pkg string) {
+	pkg = normalizePackage(pkg)
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	if o, ok := overrides[pkg]; ok {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		delete(overrides, pkg)
+		applyLevels()
+	}
+}
+
+// revert 临时级别到期，期间被重新设置过时不处理
+func revert(pkg string, o *override) {
+	levelMu.Lock()
+	if overrides[pkg] != o {
+		levelMu.Unlock()
+		return
+	}
+	delete(overrides, pkg)
+	applyLevels()
+	levelMu.Unlock()
+	lg.Info("log level override expired", zap.String("package", pkg))
+}
+
+// applyLevels 合并两层级别并生效，调用时持有 levelMu
+func applyLevels() {
+	base := cfgLevel
+	if o, ok := overrides[""]; ok {
+		base = o.level
+	}
+	atom.SetLevel(base)
+
+	t := &pkgTable{levels: make(map[string]zapcore.Level, len(cfgPkgs)+len(overrides)), min: base}
+	for pkg, l := range cfgPkgs {
+		t.levels[pkg] = l
+	}
+	for pkg, o := range overrides {
+		if pkg != "" {
+			t.levels[pkg] = o.level
+		}
+	}
+	for _, l := range t.levels {
+		if l < t.min {
+			t.min = l
+		}
+	}
+	pkgLevels.Store(t)
+}
+
+// LevelStatus 当前生效的级别
+type LevelStatus struct {
+	Level     string            `json:"level"`
+	Packages  map[string]string `json:"packages"`
+	Overrides []LevelOverride   `json:"overrides"`
+}
+
+// LevelOverride 一个临时级别
+type LevelOverride struct {
+	Package   string     `json:"package"` // 为空表示全局级别
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Levels 当前生效的全局级别、按包的级别和所有临时级别
+func Levels() LevelStatus {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	s := LevelStatus{Level: atom.Level().String(), Packages: map[string]string{}, Overrides: []LevelOverride{}}
+	for pkg, l := range pkgLevels.Load().levels {
+		s.Packages[pkg] = l.String()
+	}
+	for pkg, o := range overrides {
+		lo := LevelOverride{Package: pkg, Level: o.level.String()}
+		if !o.expires.IsZero() {
+			expires := o.expires
+			lo.ExpiresAt = &expires
+		}
+		s.Overrides = append(s.Overrides, lo)
+	}
+	sort.Slice(s.Overrides, func(i, j int) bool { return s.Overrides[i].Package < s.Overrides[j].Package })
+	return s
+}
+
+func normalizePackage(pkg string) string {
+	pkg = strings.Trim(pkg, "/")
+	return strings.TrimPrefix(strings.TrimPrefix(pkg, modulePath), "/")
+}
+
+// levelFor 日志调用方所在包的级别，按最长的包路径前缀匹配，都不匹配时使用全局级别
+func levelFor(caller zapcore.EntryCaller) zapcore.Level {
+	t := pkgLevels.Load()
+	if t == nil || len(t.levels) == 0 || !caller.Defined {
+		return atom.Level()
+	}
+	pkg := callerPackage(caller.Function)
+	level, matched := atom.Level(), -1
+	for prefix, l := range t.levels {
+		if len(prefix) > matched && (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) {
+			level, matched = l, len(prefix)
+		}
+	}
+	return level
+}
+
+// callerPackage 从函数全名中取出相对模块根目录的包路径
+// 例如 forumProject/dao/mysql.(*router).GetContext -> dao/mysql
+func callerPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		function = function[:slash+1+dot]
+	}
+	return normalizePackage(function)
+}
+
+// levelCore 在写入时按调用方所在的包过滤日志
+// 调用方要到 Check 之后才能确定，所以 Check 只按所有级别中最低的判断，Write 时再按包判断
+// 内层的core不再过滤级别
+type levelCore struct {
+	zapcore.Core
+}
+
+func (c levelCore) Enabled(l zapcore.Level) bool {
+	if t := pkgLevels.Load(); t != nil {
+		return l >= t.min
+	}
+	return atom.Enabled(l)
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{c.Core.With(fields)}
+}
+
+func (c levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level < levelFor(ent.Caller) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
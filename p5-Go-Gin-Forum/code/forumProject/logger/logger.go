@@ -11,144 +11,130 @@
 package logger
 
 import (
+	"context"
 	"forumProject/settings"
-	"net"
-	"net/http"
-	"net/http/httputil"
-	"os"
-	"runtime/debug"
-	"strings"
+	kitlogger "kit/logger"
+	"reflect"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var lg *zap.Logger
 
+// accessLg GinLogger使用的logger，开启采样时在lg外面包一层sampler
+var accessLg atomic.Pointer[zap.Logger]
+
+// atom 全局日志级别，配置热加载和管理接口直接修改，无需重建logger；按包的级别见 level.go
+var atom = zap.NewAtomicLevel()
+
 func Init(cfg *settings.LogConfig, mode string) (err error) {
-	writeSyncer := getLogWriter(
-		cfg.Filename,
-		cfg.MaxSize,
-		cfg.MaxBackups,
-		cfg.MaxAge)
-
-	encoder := getEncoder()
-	l := new(zapcore.Level)
-	err = l.UnmarshalText([]byte(cfg.Level))
-	if err != nil {
+	if err = setConfigLevels(cfg.Level, cfg.Packages); err != nil {
 		return
 	}
 
-	var core zapcore.Core
+	// 级别由 levelCore 统一过滤，内层core不再过滤
+	var core zapcore.Core = levelCore{kitlogger.FileCore(kitlogger.Options{
+		Filename:   cfg.Filename,
+		MaxSize:    cfg.MaxSize,
+		MaxAge:     cfg.MaxAge,
+		MaxBackups: cfg.MaxBackups,
+	}, zapcore.DebugLevel)}
 
 	if mode == "dev" {
-		// 进入开发模式，日志输出到终端
-		consoleEncoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
-		core = zapcore.NewTee(
-			zapcore.NewCore(encoder, writeSyncer, l),
-			// 输出到终端
-			zapcore.NewCore(consoleEncoder, zapcore.Lock(os.Stdout), zapcore.DebugLevel),
-		)
-	} else {
-		// 如果不是dev模式，就记录日志到日志文件中
-		core = zapcore.NewCore(encoder, writeSyncer, l)
+		// 进入开发模式，日志同时输出到终端
+		core = zapcore.NewTee(core, kitlogger.ConsoleCore())
 	}
 
 	lg = zap.New(core, zap.AddCaller())
+	setSampling(cfg.LogSamplingConfig)
+	setRedaction(cfg.RedactHeaders, cfg.RedactFields)
+	SetCapture(cfg.LogCaptureConfig)
 
 	zap.ReplaceGlobals(lg) //使用zap.L().Info() 替换zap.lg.Info(...)
 
+	settings.Subscribe("logger", onConfigChange, func(old, next *settings.AppConfig) {
+		next.LogConfig = old.LogConfig
+	})
 	return
 }
-func getEncoder() zapcore.Encoder {
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoderConfig.TimeKey = "time"
-	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-	encoderConfig.EncodeDuration = zapcore.SecondsDurationEncoder
-	encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
-	return zapcore.NewJSONEncoder(encoderConfig)
+
+// setSampling 重建访问日志的logger
+func setSampling(cfg *settings.LogSamplingConfig) {
+	if cfg == nil || !cfg.Enabled {
+		accessLg.Store(lg)
+		return
+	}
+	accessLg.Store(lg.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, time.Second, cfg.Initial, cfg.Thereafter)
+	})))
 }
 
-func getLogWriter(filename string, maxSize, maxBackup, maxAge int) zapcore.WriteSyncer {
-	lumberJackLogger := &lumberjack.Logger{
-		Filename:   filename,
-		MaxSize:    maxSize,
-		MaxBackups: maxBackup,
-		MaxAge:     maxAge,
+// onConfigChange 配置热加载时同步日志级别和采样参数，管理接口设置的临时级别仍然优先
+func onConfigChange(old, new *settings.AppConfig) error {
+	ol, nl := old.LogConfig, new.LogConfig
+	if ol.Level != nl.Level || !reflect.DeepEqual(ol.Packages, nl.Packages) {
+		if err := setConfigLevels(nl.Level, nl.Packages); err != nil {
+			return err
+		}
+		lg.Info("log level changed",
+			zap.String("from", ol.Level), zap.String("to", nl.Level),
+			zap.Any("packages", nl.Packages))
+	}
+	if !slices.Equal(ol.RedactHeaders, nl.RedactHeaders) || !slices.Equal(ol.RedactFields, nl.RedactFields) {
+		setRedaction(nl.RedactHeaders, nl.RedactFields)
+		lg.Info("log redaction changed", zap.Strings("headers", nl.RedactHeaders), zap.Strings("fields", nl.RedactFields))
+	}
+	// 会覆盖管理接口做的修改
+	if !reflect.DeepEqual(ol.LogCaptureConfig, nl.LogCaptureConfig) {
+		SetCapture(nl.LogCaptureConfig)
+		lg.Info("body capture changed", zap.Any("capture", Capture()))
+	}
+	if !reflect.DeepEqual(ol.LogSamplingConfig, nl.LogSamplingConfig) {
+		setSampling(nl.LogSamplingConfig)
+		lg.Info("access log sampling changed", zap.Any("sampling", nl.LogSamplingConfig))
+	}
+	return nil
+}
+
+// WithContext 返回带有 trace_id、span_id 字段的logger，方便按链路检索日志
+func WithContext(ctx context.Context) *zap.Logger {
+	return zap.L().With(traceFields(ctx)...)
+}
+
+func traceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
+
+// ginOptions 打码、采样、请求体记录和trace字段，都读取当前的设置，热加载后立即生效
+func ginOptions() kitlogger.GinOptions {
+	return kitlogger.GinOptions{
+		Redactor: redaction.Load,
+		Access:   accessLg.Load,
+		Capture:  captureLimit,
+		Fields:   traceFields,
 	}
-	return zapcore.AddSync(lumberJackLogger)
 }
 
 // GinLogger 接收gin框架默认的日志
 func GinLogger() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
-		c.Next()
-
-		cost := time.Since(start)
-		lg.Info(path,
-			zap.Int("status", c.Writer.Status()),
-			zap.String("method", c.Request.Method),
-			zap.String("path", path),
-			zap.String("query", query),
-			zap.String("ip", c.ClientIP()),
-			zap.String("user-agent", c.Request.UserAgent()),
-			zap.String("errors", c.Errors.ByType(gin.ErrorTypePrivate).String()),
-			zap.Duration("cost", cost),
-		)
-	}
+	return kitlogger.GinLogger(lg, ginOptions())
 }
 
 // GinRecovery recover掉项目可能出现的panic，并使用zap记录相关日志
 func GinRecovery(stack bool) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		defer func() {
-			if err := recover(); err != nil {
-				// Check for a broken connection, as it is not really a
-				// condition that warrants a panic stack trace.
-				var brokenPipe bool
-				if ne, ok := err.(*net.OpError); ok {
-					if se, ok := ne.Err.(*os.SyscallError); ok {
-						if strings.Contains(strings.ToLower(se.Error()), "broken pipe") || strings.Contains(strings.ToLower(se.Error()), "connection reset by peer") {
-							brokenPipe = true
-						}
-					}
-				}
-
-				httpRequest, _ := httputil.DumpRequest(c.Request, false)
-				if brokenPipe {
-					lg.Error(c.Request.URL.Path,
-						zap.Any("error", err),
-						zap.String("request", string(httpRequest)),
-					)
-					// If the connection is dead, we can't write a status to it.
-					c.Error(err.(error)) // nolint: errcheck
-					c.Abort()
-					return
-				}
-
-				if stack {
-					lg.Error("[Recovery from panic]",
-						zap.Any("error", err),
-						zap.String("request", string(httpRequest)),
-						zap.String("stack", string(debug.Stack())),
-					)
-				} else {
-					lg.Error("[Recovery from panic]",
-						zap.Any("error", err),
-						zap.String("request", string(httpRequest)),
-					)
-				}
-				c.AbortWithStatus(http.StatusInternalServerError)
-			}
-		}()
-		c.Next()
-	}
+	return kitlogger.GinRecovery(lg, stack, ginOptions())
 }
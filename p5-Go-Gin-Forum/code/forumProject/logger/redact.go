@@ -0,0 +1,14 @@
+package logger
+
+import (
+	kitlogger "kit/logger"
+	"sync/atomic"
+)
+
+// redaction 当前的打码规则，在 kitlogger 的内置列表上追加配置中的 redact_headers、redact_fields
+var redaction atomic.Pointer[kitlogger.Redactor]
+
+// setRedaction 启动和配置热加载时调用
+func setRedaction(headers, fields []string) {
+	redaction.Store(kitlogger.NewRedactor(headers, fields))
+}
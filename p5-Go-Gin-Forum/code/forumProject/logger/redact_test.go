@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"forumProject/settings"
+	"io"
+	kitlogger "kit/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestEngine 日志写到临时文件，打开请求体记录，返回引擎和日志文件路径
+func newTestEngine(t *testing.T, maxBody int) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	filename := filepath.Join(t.TempDir(), "test.log")
+	err := Init(&settings.LogConfig{
+		Level:    "debug",
+		Filename: filename,
+		MaxSize:  1,
+		LogCaptureConfig: &settings.LogCaptureConfig{
+			Enabled:       true,
+			SamplePercent: 100,
+			MaxBody:       maxBody,
+		},
+	}, "release")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(GinLogger(), GinRecovery(true))
+	// 原样返回请求体，响应中也带着敏感字段
+	r.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, c.ContentType(), body)
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return r, filename
+}
+
+func serve(r *gin.Engine, method, target, contentType, body string, header map[string]string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// secretHeaders 每个请求都带上的敏感请求头
+func secretHeaders(prefix string) map[string]string {
+	return map[string]string{
+		"Authorization":       "Bearer " + prefix + "-authorization",
+		"Proxy-Authorization": "Basic " + prefix + "-proxy",
+		"Cookie":              "session=" + prefix + "-cookie",
+		"X-Admin-Token":       prefix + "-admin-token",
+	}
+}
+
+func assertNoSecrets(t *testing.T, filename string, secrets []string, want ...string) {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	out := string(data)
+	for _, s := range secrets {
+		if strings.Contains(out, s) {
+			t.Errorf("secret %q found in log:\n%s", s, out)
+		}
+	}
+	for _, s := range want {
+		if !strings.Contains(out, s) {
+			t.Errorf("log should contain %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestSecretsNeverLogged(t *testing.T) {
+	r, filename := newTestEngine(t, 4096)
+
+	var secrets []string
+	for _, p := range []string{"json", "nested", "form", "malformed", "text", "panic"} {
+		secrets = append(secrets, p+"-authorization", p+"-proxy", p+"-cookie", p+"-admin-token")
+	}
+	secrets = append(secrets,
+		"query-token", "json-password", "json-re-password",
+		"nested-token", "nested-secret", "nested-password",
+		"form-password", "form-re-password",
+		"malformed-password", "text-password",
+		"panic-query-token",
+	)
+
+	serve(r, http.MethodPost, "/echo?page=1&token=query-token", "application/json",
+		`{"username":"alice","password":"json-password","re_password":"json-re-password"}`, secretHeaders("json"))
+	serve(r, http.MethodPost, "/echo", "application/json; charset=utf-8",
+		`{"user":{"name":"bob","token":"nested-token","keys":[{"secret":"nested-secret"}]},"list":[{"Password":"nested-password"}]}`,
+		secretHeaders("nested"))
+	serve(r, http.MethodPost, "/echo", "application/x-www-form-urlencoded",
+		"username=carol&password=form-password&re_password=form-re-password", secretHeaders("form"))
+	serve(r, http.MethodPost, "/echo", "application/json",
+		`{"username":"dave","password":"malformed-password",`, secretHeaders("malformed"))
+	serve(r, http.MethodPost, "/echo", "text/plain",
+		"password=text-password", secretHeaders("text"))
+	serve(r, http.MethodGet, "/panic?access_token=panic-query-token", "", "", secretHeaders("panic"))
+
+	assertNoSecrets(t, filename, secrets,
+		// 不敏感的内容照常记录
+		`"username\":\"alice\"`, "username=carol", "page=1", "[Recovery from panic]", kitlogger.RedactedValue,
+	)
+}
+
+func TestTruncatedJSONNotLogged(t *testing.T) {
+	r, filename := newTestEngine(t, 32)
+
+	// 密码在截断的位置之后，截断的JSON不能解析，整个请求体都不记录
+	body := `{"username":"erin","padding":"xxxxxxxxxxxxxxxx","password":"truncated-password"}`
+	serve(r, http.MethodPost, "/echo", "application/json", body, nil)
+	// 密码正好跨过截断位置
+	body = `{"a":"b","password":"cut-password-value"}`
+	serve(r, http.MethodPost, "/echo", "application/json", body, nil)
+
+	assertNoSecrets(t, filename, []string{"truncated-password", "cut-pass", "erin"}, `"body_truncated":true`)
+}
+
+func TestExtraRedactRules(t *testing.T) {
+	r, filename := newTestEngine(t, 4096)
+	setRedaction([]string{"X-Api-Key"}, []string{"pin"})
+	defer setRedaction(nil, nil)
+
+	serve(r, http.MethodPost, "/echo?pin=query-pin", "application/json",
+		`{"card":{"PIN":"json-pin"}}`, map[string]string{"X-Api-Key": "api-key-value"})
+	// 请求头只在panic时记录
+	serve(r, http.MethodGet, "/panic", "", "", map[string]string{"X-Api-Key": "panic-api-key"})
+
+	assertNoSecrets(t, filename, []string{"query-pin", "json-pin", "api-key-value", "panic-api-key"})
+}
@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"context"
+	"forumProject/dao/mysql"
+	"forumProject/pkg/scheduler"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 已删除评论的清理
+const (
+	deletedCommentRetention = 30 * 24 * time.Hour // 删除后保留的时间，期间可以恢复
+	commentPurgeBatch       = 500                 // 每批删除的条数，避免一次锁住太多行
+)
+
+// PurgeDeletedComments 定时任务 comment_cleanup：物理删除已删除超过保留期的评论
+// 还有回复的评论先保留，等回复都被清理后再删除；每批删除前确认自己仍是leader
+func PurgeDeletedComments(ctx context.Context) error {
+	// 读主库，从库落后时可能看不到刚恢复的评论和新的回复
+	ctx = mysql.WithPrimary(ctx)
+	before := time.Now().Add(-deletedCommentRetention)
+	var total int64
+	for {
+		ids, err := mysql.DeletedCommentIDs(ctx, mysql.DB(), before, commentPurgeBatch)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := scheduler.CheckFence(ctx); err != nil {
+			return err
+		}
+		n, err := mysql.PurgeComments(ctx, mysql.DB(), ids)
+		if err != nil {
+			return err
+		}
+		total += n
+		// 这一批都在查询之后被恢复或有了新回复，下一次查询还是同样的ID
+		if n == 0 || len(ids) < commentPurgeBatch {
+			break
+		}
+	}
+	zap.L().Info("deleted comments purged", zap.Int64("count", total), zap.Time("before", before))
+	return nil
+}
@@ -0,0 +1,395 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"forumProject/dao/mysql"
+	"forumProject/models"
+	"forumProject/pkg/dataio"
+	snowflake "forumProject/pkg/sonwflake"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// DataKinds 支持导入导出的数据，按依赖顺序排列，导入全部数据时也按这个顺序
+var DataKinds = []string{"users", "communities", "posts", "comments"}
+
+// IDMap 导入时旧ID到新ID的映射，保存到文件后可以在多次导入之间复用
+// 例如先导入users，再导入posts时用它改写author_id
+type IDMap struct {
+	Users    map[uint64]uint64 `json:"users"`
+	Posts    map[uint64]uint64 `json:"posts"`
+	Comments map[uint64]uint64 `json:"comments"`
+
+	base *IDMap // 一批数据的映射查不到时再查已经提交的映射，见 batch
+}
+
+// LoadIDMap 读取映射文件，文件不存在时返回空映射
+func LoadIDMap(path string) (*IDMap, error) {
+	m := &IDMap{}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			if err = json.Unmarshal(b, m); err != nil {
+				return nil, fmt.Errorf("parse id map %s failed: %w", path, err)
+			}
+		}
+	}
+	if m.Users == nil {
+		m.Users = make(map[uint64]uint64)
+	}
+	if m.Posts == nil {
+		m.Posts = make(map[uint64]uint64)
+	}
+	if m.Comments == nil {
+		m.Comments = make(map[uint64]uint64)
+	}
+	return m, nil
+}
+
+// Save 保存映射文件
+func (m *IDMap) Save(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// batch 一批数据用的映射：新分配的ID先记在这里，事务提交后才 merge 到m，回滚时直接丢弃
+// 否则失败的批次会留下指向没有入库的ID的映射，续导时引用会被改写成这些ID
+func (m *IDMap) batch() *IDMap {
+	return &IDMap{
+		Users:    make(map[uint64]uint64),
+		Posts:    make(map[uint64]uint64),
+		Comments: make(map[uint64]uint64),
+		base:     m,
+	}
+}
+
+// merge 把提交成功的一批映射合并进来
+func (m *IDMap) merge(b *IDMap) {
+	for k, v := range b.Users {
+		m.Users[k] = v
+	}
+	for k, v := range b.Posts {
+		m.Posts[k] = v
+	}
+	for k, v := range b.Comments {
+		m.Comments[k] = v
+	}
+}
+
+// lookup 有映射就返回新ID，没有就原样返回（引用的是库里已有的数据）；pick 选择用哪一类映射
+func (m *IDMap) lookup(pick func(*IDMap) map[uint64]uint64, id uint64) uint64 {
+	for ; m != nil; m = m.base {
+		if n, ok := pick(m)[id]; ok {
+			return n
+		}
+	}
+	return id
+}
+
+func userIDs(m *IDMap) map[uint64]uint64    { return m.Users }
+func postIDs(m *IDMap) map[uint64]uint64    { return m.Posts }
+func commentIDs(m *IDMap) map[uint64]uint64 { return m.Comments }
+
+// Export 把一类数据流式写出，返回写出的条数
+func Export(ctx context.Context, kind string, w dataio.Writer) (n int, err error) {
+	write := func(v interface{}) error {
+		n++
+		return w.Write(v)
+	}
+	switch kind {
+	case "users":
+		err = mysql.ExportUsers(ctx, func(u *models.User) error { return write(u) })
+	case "communities":
+		err = mysql.ExportCommunities(ctx, func(c *models.Community) error { return write(c) })
+	case "posts":
+		err = mysql.ExportPosts(ctx, func(p *models.Post) error { return write(p) })
+	case "comments":
+		err = mysql.ExportComments(ctx, func(c *models.Comment) error { return write(c) })
+	default:
+		return 0, fmt.Errorf("unknown data kind %q", kind)
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, w.Flush()
+}
+
+// ImportOptions 导入参数
+type ImportOptions struct {
+	Kind      string
+	BatchSize int    // 每个事务插入的条数
+	DryRun    bool   // 只校验不写库
+	NewIDs    bool   // 用雪花算法生成新ID，并把旧ID到新ID的映射记录到IDMap
+	IDMap     *IDMap // 多种数据一起导入时共用同一个映射
+}
+
+// RowError 某一行没有通过校验
+type RowError struct {
+	Line int    `json:"line"`
+	Msg  string `json:"msg"`
+}
+
+// ImportReport 导入结果
+type ImportReport struct {
+	Kind     string     `json:"kind"`
+	Total    int        `json:"total"`
+	Imported int        `json:"imported"`
+	Invalid  int        `json:"invalid"`
+	Errors   []RowError `json:"errors,omitempty"`
+}
+
+// Import 从r中读取一类数据，分批在事务中写入；校验失败的行会跳过并记录在报告中
+func Import(ctx context.Context, r dataio.Reader, opts ImportOptions) (*ImportReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.IDMap == nil {
+		opts.IDMap, _ = LoadIDMap("")
+	}
+
+	switch opts.Kind {
+	case "users":
+		return runImport(ctx, r, opts, userImporter)
+	case "communities":
+		return runImport(ctx, r, opts, communityImporter)
+	case "posts":
+		return runImport(ctx, r, opts, postImporter)
+	case "comments":
+		return runImport(ctx, r, opts, commentImporter)
+	}
+	return nil, fmt.Errorf("unknown data kind %q", opts.Kind)
+}
+
+// importer 描述一类数据如何校验、换ID和入库
+type importer[T any] struct {
+	validate func(*T) []string
+	// remap 先给整批数据分配新ID（assign），再改写它们引用的ID（rewrite）
+	assign  func(*T, uint64, *IDMap)
+	rewrite func(*T, *IDMap)
+	insert  func(context.Context, mysql.Querier, []*T) error
+	// invalidate 入库后删除缓存，主要是清掉这些ID的负缓存；没有缓存的数据为nil
+	invalidate func(context.Context, []*T) error
+}
+
+func runImport[T any](ctx context.Context, r dataio.Reader, opts ImportOptions, im importer[T]) (*ImportReport, error) {
+	report := &ImportReport{Kind: opts.Kind}
+	batch := make([]*T, 0, opts.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+		if opts.DryRun {
+			report.Imported += len(batch)
+			return nil
+		}
+		m := opts.IDMap.batch()
+		if opts.NewIDs {
+			ids, err := snowflake.GetIDs(len(batch))
+			if err != nil {
+				return err
+			}
+			for i, v := range batch {
+				im.assign(v, ids[i], m)
+			}
+		}
+		for _, v := range batch {
+			im.rewrite(v, m)
+		}
+		// 任何一条失败整批回滚，这一批的映射也一起丢弃
+		if err := mysql.WithTx(ctx, func(tx *sqlx.Tx) error {
+			return im.insert(ctx, tx, batch)
+		}); err != nil {
+			return err
+		}
+		opts.IDMap.merge(m)
+		report.Imported += len(batch)
+		if im.invalidate != nil {
+			if err := im.invalidate(ctx, batch); err != nil {
+				zap.L().Warn("invalidate cache after import failed", zap.String("kind", opts.Kind), zap.Error(err))
+			}
+		}
+		return nil
+	}
+
+	for {
+		v := new(T)
+		err := r.Read(v)
+		if err == io.EOF {
+			break
+		}
+		var rowErr *dataio.RowError
+		if errors.As(err, &rowErr) {
+			report.Total++
+			report.Invalid++
+			report.Errors = append(report.Errors, RowError{Line: rowErr.Line, Msg: rowErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Total++
+		if problems := im.validate(v); len(problems) > 0 {
+			report.Invalid++
+			for _, p := range problems {
+				report.Errors = append(report.Errors, RowError{Line: r.Line(), Msg: p})
+			}
+			continue
+		}
+
+		batch = append(batch, v)
+		if len(batch) == opts.BatchSize {
+			if err = flush(); err != nil {
+				return report, fmt.Errorf("import batch ending at line %d failed: %w", r.Line(), err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, fmt.Errorf("import last batch failed: %w", err)
+	}
+	return report, nil
+}
+
+// 校验规则和 create_table.sql 中的字段定义保持一致
+
+type rules []string
+
+func (r *rules) required(field, v string, max int) {
+	switch n := utf8.RuneCountInString(v); {
+	case n == 0:
+		*r = append(*r, field+": is required")
+	case n > max:
+		*r = append(*r, fmt.Sprintf("%s: must be at most %d characters, got %d", field, max, n))
+	}
+}
+
+func (r *rules) maxLen(field, v string, max int) {
+	if n := utf8.RuneCountInString(v); n > max {
+		*r = append(*r, fmt.Sprintf("%s: must be at most %d characters, got %d", field, max, n))
+	}
+}
+
+func (r *rules) id(field string, v uint64) {
+	if v == 0 {
+		*r = append(*r, field+": is required")
+	}
+}
+
+// defaultTime 导出文件里没有create_time时使用导入时间
+func defaultTime(t *time.Time) {
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+var userImporter = importer[models.User]{
+	validate: func(u *models.User) []string {
+		var r rules
+		r.id("user_id", u.UserID)
+		r.required("username", u.UserName, 64)
+		r.required("password", u.Password, 64)
+		r.maxLen("email", u.Email, 64)
+		defaultTime(&u.CreateTime)
+		return r
+	},
+	assign: func(u *models.User, id uint64, m *IDMap) {
+		m.Users[u.UserID] = id
+		u.UserID = id
+	},
+	rewrite: func(*models.User, *IDMap) {},
+	insert:  mysql.ImportUsers,
+	invalidate: func(ctx context.Context, users []*models.User) error {
+		ids := make([]uint64, len(users))
+		for i, u := range users {
+			ids[i] = u.UserID
+		}
+		return invalidateUsers(ctx, ids...)
+	},
+}
+
+var communityImporter = importer[models.Community]{
+	validate: func(c *models.Community) []string {
+		var r rules
+		r.id("community_id", c.CommunityID)
+		if c.CommunityID > 1<<32-1 {
+			r = append(r, "community_id: must fit in int(10) unsigned")
+		}
+		r.required("community_name", c.CommunityName, 128)
+		r.required("introduction", c.Introduction, 256)
+		defaultTime(&c.CreateTime)
+		return r
+	},
+	// 社区ID是手工维护的小整数，不换ID
+	assign:  func(*models.Community, uint64, *IDMap) {},
+	rewrite: func(*models.Community, *IDMap) {},
+	insert:  mysql.ImportCommunities,
+}
+
+var postImporter = importer[models.Post]{
+	validate: func(p *models.Post) []string {
+		var r rules
+		r.id("post_id", p.PostID)
+		r.required("title", p.Title, 128)
+		r.required("content", p.Content, 8192)
+		r.id("author_id", p.AuthorID)
+		r.id("community_id", p.CommunityID)
+		defaultTime(&p.CreateTime)
+		return r
+	},
+	assign: func(p *models.Post, id uint64, m *IDMap) {
+		m.Posts[p.PostID] = id
+		p.PostID = id
+	},
+	rewrite: func(p *models.Post, m *IDMap) {
+		p.AuthorID = m.lookup(userIDs, p.AuthorID)
+	},
+	insert: mysql.ImportPosts,
+	invalidate: func(ctx context.Context, posts []*models.Post) error {
+		ids := make([]uint64, len(posts))
+		for i, p := range posts {
+			ids[i] = p.PostID
+		}
+		return invalidatePosts(ctx, ids...)
+	},
+}
+
+var commentImporter = importer[models.Comment]{
+	validate: func(c *models.Comment) []string {
+		var r rules
+		r.id("comment_id", c.CommentID)
+		r.required("content", c.Content, 65535)
+		r.id("post_id", c.PostID)
+		r.id("author_id", c.AuthorID)
+		defaultTime(&c.CreateTime)
+		return r
+	},
+	assign: func(c *models.Comment, id uint64, m *IDMap) {
+		m.Comments[c.CommentID] = id
+		c.CommentID = id
+	},
+	// 父评论需要出现在子评论之前，导出时按id排序可以保证这一点
+	rewrite: func(c *models.Comment, m *IDMap) {
+		c.PostID = m.lookup(postIDs, c.PostID)
+		c.AuthorID = m.lookup(userIDs, c.AuthorID)
+		if c.ParentID != 0 {
+			c.ParentID = m.lookup(commentIDs, c.ParentID)
+		}
+	},
+	insert: mysql.ImportComments,
+}
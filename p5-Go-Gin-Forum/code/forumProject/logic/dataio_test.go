@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIDMapBatch(t *testing.T) {
+	m, _ := LoadIDMap("")
+	m.Users[1] = 101
+
+	// 回滚的批次：映射不能留下来
+	failed := m.batch()
+	failed.Users[2] = 102
+	failed.Posts[10] = 110
+	if got := failed.lookup(userIDs, 1); got != 101 {
+		t.Errorf("batch should fall back to committed map, got %d", got)
+	}
+	if got := m.lookup(userIDs, 2); got != 2 {
+		t.Errorf("unmerged batch leaked into map: users[2] = %d", got)
+	}
+
+	// 同一批里引用本批新分配的ID，如子评论引用父评论
+	ok := m.batch()
+	ok.Comments[20] = 120
+	if got := ok.lookup(commentIDs, 20); got != 120 {
+		t.Errorf("lookup within batch = %d, want 120", got)
+	}
+	m.merge(ok)
+	if got := m.lookup(commentIDs, 20); got != 120 {
+		t.Errorf("merged comments[20] = %d, want 120", got)
+	}
+
+	// 保存的文件中只有提交成功的映射
+	path := filepath.Join(t.TempDir(), "idmap.json")
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIDMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Users) != 1 || len(loaded.Posts) != 0 || len(loaded.Comments) != 1 {
+		t.Errorf("saved map = %+v, want only committed mappings", loaded)
+	}
+}
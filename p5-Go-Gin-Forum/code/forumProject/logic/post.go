@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"forumProject/dao/mysql"
+	"forumProject/dao/redis"
+	"forumProject/models"
+	"strconv"
+	"time"
+)
+
+// postCache 帖子缓存，写库成功之后要调用 invalidatePosts
+// 目前修改帖子的只有数据导入，以后加编辑、修改状态、删除帖子时都要调用
+var postCache = redis.NewCache[models.Post]("post", redis.CacheOptions{
+	TTL:         10 * time.Minute,
+	Jitter:      2 * time.Minute,
+	NegativeTTL: time.Minute,
+})
+
+// invalidatePosts 删除帖子缓存
+func invalidatePosts(ctx context.Context, postIDs ...uint64) error {
+	return postCache.Invalidate(ctx, formatIDs(postIDs)...)
+}
+
+// GetPost 查询帖子，优先读缓存
+func GetPost(ctx context.Context, postID uint64) (*models.Post, error) {
+	post, err := postCache.Get(ctx, strconv.FormatUint(postID, 10), func(ctx context.Context) (*models.Post, error) {
+		p, err := mysql.GetPostByID(ctx, mysql.DB(), postID)
+		if errors.Is(err, mysql.ErrorPostNotExist) {
+			return nil, nil
+		}
+		return p, err
+	})
+	if err == nil && post == nil {
+		err = mysql.ErrorPostNotExist
+	}
+	return post, err
+}
+
+func GetPostDetail(ctx context.Context, postID uint64) (*models.PostDetail, error) {
+
+	// 1.查帖子
+	post, err := GetPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2.查作者，作者被删了也照常展示帖子
+	detail := &models.PostDetail{Post: post}
+	author, err := GetUser(ctx, post.AuthorID)
+	if err != nil && !errors.Is(err, mysql.ErrorUserNotExist) {
+		return nil, err
+	}
+	if author != nil {
+		detail.AuthorName = author.UserName
+	}
+	return detail, nil
+}
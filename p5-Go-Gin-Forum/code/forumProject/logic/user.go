@@ -1,19 +1,29 @@
 package logic
 
 import (
+	"context"
+	"errors"
 	"forumProject/dao/mysql"
+	"forumProject/dao/redis"
 	"forumProject/models"
 	snowflake "forumProject/pkg/sonwflake"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
-func SignUp(p *models.ParamSignUp) (err error) {
+// userCache 用户缓存，写库成功之后要调用 invalidateUsers
+// 目前修改用户的只有数据导入；注册用的是新生成的ID，不会有旧缓存。以后加修改资料、改密码、删除用户时都要调用
+var userCache = redis.NewCache[models.User]("user", redis.CacheOptions{
+	TTL:         30 * time.Minute,
+	Jitter:      5 * time.Minute,
+	NegativeTTL: time.Minute,
+})
 
-	// 1.判断用户是否存在
-	if err = mysql.CheckUserExist(p.Username); err != nil {
-		return err
-	}
+func SignUp(ctx context.Context, p *models.ParamSignUp) (err error) {
 
-	// 2.生成UID
+	// 1.生成UID，放在事务外面，死锁重试时不会重复生成
 	var userID uint64
 	if userID, err = snowflake.GetID(); err != nil {
 		return err
@@ -24,21 +34,52 @@ func SignUp(p *models.ParamSignUp) (err error) {
 		Password: p.Password,
 	}
 
-	// 3.入库
-	if err = mysql.InsertUser(user); err != nil {
-		return err
-	}
-
-	return
+	// 2.判断用户是否存在并入库，同一个事务中完成
+	return mysql.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := mysql.CheckUserExist(ctx, tx, p.Username); err != nil {
+			return err
+		}
+		return mysql.InsertUser(ctx, tx, user)
+	})
 }
 
-func Login(p *models.ParamLogin) error {
+func Login(ctx context.Context, p *models.ParamLogin) error {
 
 	// 实例化user
 	user := &models.User{
 		UserName: p.Username,
 		Password: p.Password,
 	}
-	return mysql.Login(user)
+	// 校验密码读主库：刚注册或刚改密码时从库可能还没同步，会误判为用户不存在或密码错误
+	return mysql.Login(mysql.WithPrimary(ctx), mysql.DB(), user)
+
+}
+
+// invalidateUsers 删除用户缓存
+func invalidateUsers(ctx context.Context, userIDs ...uint64) error {
+	return userCache.Invalidate(ctx, formatIDs(userIDs)...)
+}
+
+// formatIDs 把ID转换成缓存的key
+func formatIDs(ids []uint64) []string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.FormatUint(id, 10)
+	}
+	return s
+}
 
+// GetUser 查询用户的公开信息，优先读缓存
+func GetUser(ctx context.Context, userID uint64) (*models.User, error) {
+	user, err := userCache.Get(ctx, strconv.FormatUint(userID, 10), func(ctx context.Context) (*models.User, error) {
+		u, err := mysql.GetUserByID(ctx, mysql.DB(), userID)
+		if errors.Is(err, mysql.ErrorUserNotExist) {
+			return nil, nil
+		}
+		return u, err
+	})
+	if err == nil && user == nil {
+		err = mysql.ErrorUserNotExist
+	}
+	return user, err
 }
@@ -17,18 +17,24 @@ import (
 	"forumProject/dao/mysql"
 	"forumProject/dao/redis"
 	"forumProject/logger"
+	"forumProject/logic"
+	"forumProject/pkg/jobs"
+	"forumProject/pkg/scheduler"
 	snowflake "forumProject/pkg/sonwflake"
+	"forumProject/pkg/tracing"
 	"forumProject/routes"
 	"forumProject/settings"
-	"net/http"
+	"kit/graceful"
+	"kit/server"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// readyTimeout SIGHUP重启时等待新进程就绪的最长时间
+const readyTimeout = 30 * time.Second
+
 func main() {
 
 	// 0. flag命令行参数指定配置文件
@@ -36,26 +42,47 @@ func main() {
 	flag.StringVar(&configFileName, "config", "./config.yaml", "配置文件")
 	flag.Parse()
 
+	// 带子命令时只执行子命令，例如 forumProject config check
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(configFileName, flag.Args()))
+	}
+
 	// 1. 加载配置文件
 	if err := settings.Init(configFileName); err != nil {
-		fmt.Printf("init settings failed, err:%#v\n", err)
+		fmt.Printf("init settings failed, err:%v\n", err)
 		return
 	}
 	fmt.Println("settings init success...")
+	// 启动阶段统一使用同一份快照，运行中需要最新值的地方再调用 settings.Get()
+	conf := settings.Get()
 
 	// 2. 初始化日志
-	if err := logger.Init(settings.Conf.LogConfig, settings.Conf.Mode); err != nil {
+	if err := logger.Init(conf.LogConfig, conf.Mode); err != nil {
 		fmt.Printf("init logger failed, err:%#v\n", err)
 		return
 	}
 	defer zap.L().Sync()
+	logger.InitAudit(conf.AuditConfig)
 	zap.L().Debug("logger init success...")
+	zap.L().Info("effective config", zap.Any("config", conf.Redacted()))
+
+	// 初始化链路追踪
+	shutdownTracer, err := tracing.Init(conf.TraceConfig, conf.Name, conf.Version)
+	if err != nil {
+		fmt.Printf("init tracing failed, err:%v\n", err)
+		return
+	}
+	defer func() {
+		// 退出前把还没导出的span刷出去
+		_ = shutdownTracer(context.Background())
+	}()
+	zap.L().Debug("tracing init success...")
 
 	/*
 		3. 初始化数据库链接
 	*/
 	// 3.1 初始化MySQL连接（sqlx）
-	if err := mysql.Init(settings.Conf.MySQLConfig); err != nil {
+	if err := mysql.Init(conf.MySQLConfig); err != nil {
 		fmt.Printf("init mysql failed, err:%v\n", err)
 		return
 	}
@@ -63,18 +90,37 @@ func main() {
 	zap.L().Debug("mysql init success...")
 
 	// 3.2 初始化Redis连接（go-redis）
-	if err := redis.Init(settings.Conf.RedisConfig); err != nil {
+	if err := redis.Init(conf.RedisConfig); err != nil {
 		fmt.Printf("init redis failed, err:%v\n", err)
 		return
 	}
 	defer redis.Close()
 	zap.L().Debug("redis init success...")
 
+	// SIGHUP重启时通过 FORUM_LISTEN_FD 等环境变量把socket交给新进程，
+	// 在初始化雪花算法之前设置，initSnowflake 需要判断是不是重启出来的进程
+	graceful.EnvPrefix = settings.EnvPrefix
+
 	//雪花算法初始化：得到一个不重复的user_id
-	if err := snowflake.Init(settings.Conf.MachineID); err != nil {
+	if err = initSnowflake(conf); err != nil {
 		fmt.Printf("init snowflake failed, err:%v\n", err)
 		return
 	}
+	defer snowflake.Close()
+
+	// 后台任务队列：worker在收到退出信号后会等正在执行的任务完成
+	jobs.Init(&redis.JobBroker{}, jobOptions(conf.JobsConfig))
+	jobs.Start()
+
+	// 定时任务：每个实例都启动，只有抢到redis锁的leader执行
+	if sc := conf.SchedulerConfig; sc != nil && sc.Enabled {
+		scheduler.Register("comment_cleanup", "30 3 * * *", logic.PurgeDeletedComments)
+		scheduler.Init(redis.SchedulerStore{}, scheduler.Options{
+			LockTTL:     time.Duration(sc.LockTTL) * time.Second,
+			HistorySize: sc.HistorySize,
+		})
+		scheduler.Start()
+	}
 
 	// 注册翻译器
 	if err := controller.InitTrans("zh"); err != nil {
@@ -83,37 +129,61 @@ func main() {
 	}
 
 	// 4. 路由注册
-	r := routes.Setup(settings.Conf.Mode)
+	r := routes.Setup(conf.Mode)
 	zap.L().Debug("routes init success...")
 
 	// 5. 启动服务（优雅关机）
-	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", settings.Conf.Port),
-		Handler: r,
+	err = server.Run(r, server.Options{
+		Addr: fmt.Sprintf(":%d", conf.Port),
+		// 关闭时读取最新配置，热加载修改的wait_time也能生效
+		WaitTime:     func() time.Duration { return time.Duration(settings.Get().WaitTime) * time.Second },
+		Graceful:     true,
+		ReadyTimeout: readyTimeout,
+		OnShutdown: []func(ctx context.Context){
+			// 请求都处理完了，不会再有新任务投递进来，再等worker把手上的任务做完
+			func(ctx context.Context) {
+				if err := jobs.Shutdown(ctx); err != nil {
+					zap.L().Error("后台任务没有全部完成，未确认的任务会由其他实例重新执行", zap.Error(err))
+				}
+			},
+			// 释放leader锁，其他实例下一次选举就能接手
+			func(ctx context.Context) {
+				if err := scheduler.Stop(ctx); err != nil {
+					zap.L().Error("定时任务没有全部完成", zap.Error(err))
+				}
+			},
+		},
+	})
+	if err != nil {
+		// 不用Fatal，否则上面defer的租约释放、redis和mysql的关闭都不会执行
+		zap.L().Error("server exited", zap.Error(err))
 	}
-	go func() {
-		// 开启一个goroutine启动服务
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			zap.L().Fatal("listen: " + err.Error())
-		}
-	}()
-	//
-	// 等待中断信号来优雅地关闭服务器，为关闭服务器操作设置一个5秒的超时
-	quit := make(chan os.Signal, 1) // 创建一个接收信号的通道
-	// kill 默认会发送 syscall.SIGTERM 信号
-	// kill -2 发送 syscall.SIGINT 信号，我们常用的Ctrl+C就是触发系统SIGINT信号
-	// kill -9 发送 syscall.SIGKILL 信号，但是不能被捕获，所以不需要添加它
-	// signal.Notify把收到的 syscall.SIGINT或syscall.SIGTERM 信号转发给quit
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM) // 此处不会阻塞
-	<-quit                                               // 阻塞在此，当接收到上述两种信号时才会往下执行
-	zap.L().Info(fmt.Sprintf("触发关闭等待服务，将等待%ds", settings.Conf.WaitTime))
-	// 创建一个5秒超时的context
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	// 5秒内优雅关闭服务（将未处理完的请求处理完再关闭服务），超过5秒就超时退出
-	if err := srv.Shutdown(ctx); err != nil {
-		zap.L().Fatal(fmt.Sprintf("等了%ds了还没好，先撤了...", settings.Conf.WaitTime), zap.Error(err))
+}
+
+// initSnowflake 开启租约时从redis租用机器ID，保证多实例不会生成重复的ID；否则使用配置中的machine_id
+func initSnowflake(conf *settings.AppConfig) error {
+	if lc := conf.LeaseConfig; lc != nil && lc.Enabled {
+		return snowflake.InitLease(redis.MachineIDLeaser{}, conf.MachineID, lc.MaxMachineID, time.Duration(lc.TTL)*time.Second)
 	}
+	// SIGHUP重启时新旧进程会同时运行一段时间，使用同一个machine_id会生成重复的ID；
+	// 新进程直接退出，旧进程收到失败后继续提供服务
+	if graceful.Inherited() {
+		return fmt.Errorf("restart with SIGHUP requires machine_id_lease.enabled, otherwise the old and new process both use machine_id %d", conf.MachineID)
+	}
+	return snowflake.Init(conf.MachineID)
+}
 
-	zap.L().Info("所有请求处理完成,服务正常退出")
+// jobOptions 没有jobs配置段时使用默认参数，不启动worker
+func jobOptions(cfg *settings.JobsConfig) jobs.Options {
+	if cfg == nil {
+		return jobs.Options{}
+	}
+	return jobs.Options{
+		Workers:           cfg.Workers,
+		MaxAttempts:       cfg.MaxAttempts,
+		Backoff:           time.Duration(cfg.Backoff) * time.Millisecond,
+		MaxBackoff:        time.Duration(cfg.MaxBackoff) * time.Millisecond,
+		Timeout:           time.Duration(cfg.Timeout) * time.Second,
+		VisibilityTimeout: time.Duration(cfg.VisibilityTimeout) * time.Second,
+	}
 }
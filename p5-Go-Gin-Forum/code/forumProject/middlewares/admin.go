@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"forumProject/settings"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHeader 管理接口的令牌请求头
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminAuth 管理接口鉴权：请求头中的令牌必须和配置的 admin_token 一致
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 每次读取最新配置，修改令牌后无需重启
+		expected := settings.Get().AdminToken
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"msg": "管理接口未开启",
+			})
+			return
+		}
+		token := c.GetHeader(AdminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"msg": "无权访问",
+			})
+			return
+		}
+		c.Next()
+	}
+}
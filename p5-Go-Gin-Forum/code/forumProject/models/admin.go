@@ -0,0 +1,16 @@
+package models
+
+// ParamLogLevel 临时修改日志级别的参数
+type ParamLogLevel struct {
+	Level   string `json:"level" binding:"required,oneof=debug info warn error dpanic panic fatal"`
+	Package string `json:"package"`             // 包路径，如 dao、dao/mysql，为空表示全局级别
+	TTL     int    `json:"ttl" binding:"min=0"` // 多少秒后恢复为配置的级别，0表示一直生效到重启或手动撤销
+}
+
+// ParamLogCapture 修改请求体记录设置的参数，在下次修改配置文件之前一直生效
+type ParamLogCapture struct {
+	Enabled       bool     `json:"enabled"`
+	Routes        []string `json:"routes"`
+	SamplePercent float64  `json:"sample_percent" binding:"min=0,max=100"`
+	MaxBody       int      `json:"max_body" binding:"required_if=Enabled true,min=0"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// 评论的状态
+const (
+	CommentStatusDeleted uint8 = 0 // 已删除，保留一段时间后由 comment_cleanup 定时任务物理删除
+	CommentStatusNormal  uint8 = 1
+)
+
+type Comment struct {
+	CommentID  uint64    `json:"comment_id" db:"comment_id"`
+	Content    string    `json:"content" db:"content"`
+	PostID     uint64    `json:"post_id" db:"post_id"`
+	AuthorID   uint64    `json:"author_id" db:"author_id"`
+	ParentID   uint64    `json:"parent_id" db:"parent_id"`
+	Status     uint8     `json:"status" db:"status"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Post struct {
+	PostID      uint64    `json:"post_id" db:"post_id"`
+	Title       string    `json:"title" db:"title"`
+	Content     string    `json:"content" db:"content"`
+	AuthorID    uint64    `json:"author_id" db:"author_id"`
+	CommunityID uint64    `json:"community_id" db:"community_id"`
+	Status      int8      `json:"status" db:"status"`
+	CreateTime  time.Time `json:"create_time" db:"create_time"`
+}
+
+// ParamPostDetail 帖子详情的路径参数
+type ParamPostDetail struct {
+	PostID uint64 `json:"id" uri:"id" binding:"required,snowflake"`
+}
+
+// PostDetail 帖子详情页的数据
+type PostDetail struct {
+	*Post
+	AuthorName string `json:"author_name"`
+}
@@ -1,9 +1,11 @@
 package models
 
+import "time"
+
 type ParamSignUp struct {
-	Username   string `json:"username" binding:"required"`
-	Password   string `json:"password" binding:"required"`
-	RePassword string `json:"re_password" binding:"required,eqfield=Password"`
+	Username   string `json:"username" binding:"required,username"`
+	Password   string `json:"password" binding:"required,password"` // 强度要求见配置 password_policy
+	RePassword string `json:"re_password" binding:"required"`       // 是否和password一致由SignUpParamStructLevelValidation校验
 }
 
 type ParamLogin struct {
@@ -12,7 +14,10 @@ type ParamLogin struct {
 }
 
 type User struct {
-	UserID   uint64 `json:"user_id"`
-	UserName string `json:"username"`
-	Password string `json:"password"`
+	UserID     uint64    `json:"user_id" db:"user_id"`
+	UserName   string    `json:"username" db:"username"`
+	Password   string    `json:"password" db:"password"`
+	Email      string    `json:"email" db:"email"`
+	Gender     int8      `json:"gender" db:"gender"`
+	CreateTime time.Time `json:"create_time" db:"create_time"`
 }
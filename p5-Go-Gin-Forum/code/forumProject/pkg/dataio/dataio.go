@@ -0,0 +1,244 @@
+// dataio 以NDJSON或CSV格式流式读写结构体，字段名取json标签
+package dataio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// Writer 逐条写出记录
+type Writer interface {
+	Write(v interface{}) error
+	Flush() error
+}
+
+// Reader 逐条读入记录，读完时返回 io.EOF；Line 返回刚读到的记录所在的行号，用于报告错误
+type Reader interface {
+	Read(v interface{}) error
+	Line() int
+}
+
+// RowError 某一行的数据无法解析，跳过这一行可以继续读下去
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+func (e *RowError) Unwrap() error { return e.Err }
+
+// NewWriter 按格式创建Writer
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// NewReader 按格式创建Reader
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatNDJSON:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), 16*1024*1024) // 帖子内容可能比较长
+		return &ndjsonReader{s: s}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+		return &csvReader{r: cr}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(v interface{}) error { return w.enc.Encode(v) }
+func (w *ndjsonWriter) Flush() error              { return w.w.Flush() }
+
+type ndjsonReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (r *ndjsonReader) Read(v interface{}) error {
+	for r.s.Scan() {
+		r.line++
+		b := r.s.Bytes()
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return &RowError{Line: r.line, Err: err}
+		}
+		return nil
+	}
+	if err := r.s.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func (r *ndjsonReader) Line() int { return r.line }
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (w *csvWriter) Write(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields := columns(rv.Type())
+	if !w.header {
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.name
+		}
+		if err := w.w.Write(names); err != nil {
+			return err
+		}
+		w.header = true
+	}
+
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = format(rv.Field(f.index))
+	}
+	return w.w.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func (r *csvReader) Read(v interface{}) error {
+	if r.header == nil {
+		h, err := r.r.Read()
+		if err != nil {
+			return err
+		}
+		r.header = append([]string(nil), h...)
+	}
+	record, err := r.r.Read()
+	if errors.Is(err, csv.ErrFieldCount) {
+		return &RowError{Line: r.Line(), Err: err}
+	}
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	byName := make(map[string]column)
+	for _, f := range columns(rv.Type()) {
+		byName[f.name] = f
+	}
+	for i, name := range r.header {
+		f, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown column %q", name)
+		}
+		if err := parse(rv.Field(f.index), record[i]); err != nil {
+			return &RowError{Line: r.Line(), Err: fmt.Errorf("%s: %w", name, err)}
+		}
+	}
+	return nil
+}
+
+func (r *csvReader) Line() int {
+	line, _ := r.r.FieldPos(0)
+	return line
+}
+
+type column struct {
+	name  string
+	index int
+}
+
+func columns(t reflect.Type) []column {
+	cols := make([]column, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		cols = append(cols, column{name: name, index: i})
+	}
+	return cols
+}
+
+func format(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func parse(v reflect.Value, s string) error {
+	if _, ok := v.Interface().(time.Time); ok {
+		if s == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
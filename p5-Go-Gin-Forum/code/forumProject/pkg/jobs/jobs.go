@@ -0,0 +1,122 @@
+// jobs 后台任务队列：请求处理中只投递任务，由worker异步执行
+// 任务至少执行一次，worker挂掉时未确认的任务会被其他worker重新执行，所以处理函数要保证幂等
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	snowflake "forumProject/pkg/sonwflake"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNotInitialized 还没有调用 Init
+var ErrNotInitialized = errors.New("jobs: not initialized")
+
+// Job 队列中的一个任务
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"` // 已经执行过的次数
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	LastError  string          `json:"last_error,omitempty"`
+}
+
+// Delivery worker取到的一个任务，Ack时需要用到它在队列中的ID
+type Delivery struct {
+	MessageID string
+	Job       *Job
+}
+
+// Broker 任务的存储，由 dao/redis.JobBroker 实现
+type Broker interface {
+	// Push 放进待执行队列
+	Push(ctx context.Context, job *Job) error
+	// Schedule 到 at 时刻才放进待执行队列
+	Schedule(ctx context.Context, job *Job, at time.Time) error
+	// PromoteDue 把已经到期的延时任务放进待执行队列，返回移动的数量
+	PromoteDue(ctx context.Context, now time.Time, limit int) (int, error)
+	// Fetch 取任务，优先认领超过 minIdle 没有确认的任务，没有任务时最多阻塞 block
+	Fetch(ctx context.Context, consumer string, minIdle, block time.Duration) (*Delivery, error)
+	// Ack 确认任务已经处理完（成功、已安排重试或已进入死信队列）
+	Ack(ctx context.Context, d *Delivery) error
+	// Bury 放进死信队列
+	Bury(ctx context.Context, job *Job) error
+}
+
+// Handler 处理一类任务，payload 是投递时的参数序列化后的JSON
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]Handler{}
+	broker   Broker
+)
+
+// Register 注册一类任务的处理函数，应在 Start 之前调用
+// T 为任务参数的类型，投递时用 Enqueue 传入同样类型的参数
+func Register[T any](jobType string, fn func(ctx context.Context, payload T) error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := handlers[jobType]; ok {
+		panic("jobs: duplicate handler for " + jobType)
+	}
+	handlers[jobType] = func(ctx context.Context, raw json.RawMessage) error {
+		var p T
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		return fn(ctx, p)
+	}
+}
+
+func handler(jobType string) (Handler, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	h, ok := handlers[jobType]
+	return h, ok
+}
+
+// Enqueue 投递一个立即执行的任务，返回任务ID
+func Enqueue(ctx context.Context, jobType string, payload interface{}) (string, error) {
+	return EnqueueAt(ctx, jobType, payload, time.Time{})
+}
+
+// EnqueueIn 投递一个延时任务
+func EnqueueIn(ctx context.Context, jobType string, payload interface{}, delay time.Duration) (string, error) {
+	return EnqueueAt(ctx, jobType, payload, time.Now().Add(delay))
+}
+
+// EnqueueAt 投递一个在指定时间执行的任务，at 为零值或已经过去时立即执行
+func EnqueueAt(ctx context.Context, jobType string, payload interface{}, at time.Time) (string, error) {
+	if broker == nil {
+		return "", ErrNotInitialized
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("jobs: encode payload: %w", err)
+	}
+	id, err := snowflake.GetID()
+	if err != nil {
+		return "", err
+	}
+	job := &Job{
+		ID:         strconv.FormatUint(id, 10),
+		Type:       jobType,
+		Payload:    raw,
+		EnqueuedAt: time.Now(),
+	}
+	if at.After(job.EnqueuedAt) {
+		err = broker.Schedule(ctx, job, at)
+	} else {
+		err = broker.Push(ctx, job)
+	}
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	fetchBlock      = 2 * time.Second // 没有任务时每次阻塞等待的时间，也决定了关闭时最多多等多久
+	promoteInterval = time.Second     // 检查延时任务是否到期的间隔
+	promoteBatch    = 100             // 每次最多移动的延时任务数
+	errorDelay      = time.Second     // redis出错后等一会再取，避免空转
+	brokerTimeout   = 5 * time.Second // Ack、重试等收尾操作的超时
+	defaultTimeout  = time.Minute     // 单个任务默认的执行超时
+	defaultIdle     = 5 * time.Minute // 默认的 VisibilityTimeout
+	defaultBackoff  = time.Second     // 默认第一次重试的等待时间
+	defaultMaxWait  = 5 * time.Minute // 默认重试等待时间的上限
+	defaultAttempts = 5               // 默认最多执行次数
+)
+
+// Options worker的参数，零值使用默认值
+type Options struct {
+	Workers           int
+	MaxAttempts       int
+	Backoff           time.Duration
+	MaxBackoff        time.Duration
+	Timeout           time.Duration
+	VisibilityTimeout time.Duration
+}
+
+var (
+	options Options
+	pool    *workerPool
+)
+
+type workerPool struct {
+	consumer   string
+	fetchCtx   context.Context // Shutdown 时取消，停止取新任务
+	stopFetch  context.CancelFunc
+	jobCtx     context.Context // 等待超时后取消，通知正在执行的任务退出
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// Init 设置任务存储和worker参数，之后就可以投递任务了
+func Init(b Broker, opts Options) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxWait
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = defaultIdle
+	}
+	broker, options = b, opts
+}
+
+// Start 启动worker和延时任务的搬运，Workers为0时什么也不做
+func Start() {
+	if broker == nil || options.Workers <= 0 {
+		return
+	}
+	host, _ := os.Hostname()
+	p := &workerPool{consumer: fmt.Sprintf("%s-%d", host, os.Getpid())}
+	p.fetchCtx, p.stopFetch = context.WithCancel(context.Background())
+	p.jobCtx, p.cancelJobs = context.WithCancel(context.Background())
+
+	p.wg.Add(options.Workers + 1)
+	go p.promote()
+	for i := 0; i < options.Workers; i++ {
+		go p.work()
+	}
+	pool = p
+	zap.L().Info("job workers started", zap.Int("workers", options.Workers), zap.String("consumer", p.consumer))
+}
+
+// Shutdown 停止取新任务并等待正在执行的任务完成；ctx到期后取消还没完成的任务，
+// 它们没有被确认，会在 VisibilityTimeout 之后由其他worker重新执行
+func Shutdown(ctx context.Context) error {
+	p := pool
+	if p == nil {
+		return nil
+	}
+	p.stopFetch()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		zap.L().Info("job workers stopped")
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		<-done
+		return fmt.Errorf("jobs: in-flight jobs cancelled: %w", ctx.Err())
+	}
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+	for p.fetchCtx.Err() == nil {
+		d, err := broker.Fetch(p.fetchCtx, p.consumer, options.VisibilityTimeout, fetchBlock)
+		if err != nil {
+			if p.fetchCtx.Err() != nil {
+				return
+			}
+			zap.L().Warn("fetch job failed", zap.Error(err))
+			p.sleep(errorDelay)
+			continue
+		}
+		if d != nil {
+			p.process(d)
+		}
+	}
+}
+
+func (p *workerPool) process(d *Delivery) {
+	job := d.Job
+	start := time.Now()
+	err := p.run(job)
+	job.Attempt++
+
+	ctx, cancel := context.WithTimeout(context.Background(), brokerTimeout)
+	defer cancel()
+	log := zap.L().With(zap.String("job_id", job.ID), zap.String("type", job.Type), zap.Int("attempt", job.Attempt))
+
+	if err == nil {
+		log.Debug("job done", zap.Duration("cost", time.Since(start)))
+	} else {
+		job.LastError = err.Error()
+		if job.Attempt >= options.MaxAttempts {
+			log.Error("job failed, move to dead letter queue", zap.Error(err))
+			err = broker.Bury(ctx, job)
+		} else {
+			wait := backoff(job.Attempt)
+			log.Warn("job failed, retry later", zap.Duration("wait", wait), zap.Error(err))
+			err = broker.Schedule(ctx, job, time.Now().Add(wait))
+		}
+		if err != nil {
+			// 不确认，等 VisibilityTimeout 之后重新执行
+			log.Error("reschedule job failed", zap.Error(err))
+			return
+		}
+	}
+	if err = broker.Ack(ctx, d); err != nil {
+		log.Error("ack job failed", zap.Error(err))
+	}
+}
+
+// run 执行任务，处理函数panic时当作失败
+func (p *workerPool) run(job *Job) (err error) {
+	h, ok := handler(job.Type)
+	if !ok {
+		return fmt.Errorf("no handler for job type %q", job.Type)
+	}
+	ctx, cancel := context.WithTimeout(p.jobCtx, options.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, job.Payload)
+}
+
+// promote 定期把到期的延时任务（包括等待重试的任务）放进待执行队列
+func (p *workerPool) promote() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(promoteInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.fetchCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		for {
+			n, err := broker.PromoteDue(p.fetchCtx, time.Now(), promoteBatch)
+			if err != nil {
+				if p.fetchCtx.Err() == nil {
+					zap.L().Warn("promote delayed jobs failed", zap.Error(err))
+				}
+				break
+			}
+			if n < promoteBatch {
+				break
+			}
+		}
+	}
+}
+
+func (p *workerPool) sleep(d time.Duration) {
+	select {
+	case <-p.fetchCtx.Done():
+	case <-time.After(d):
+	}
+}
+
+// backoff 第n次失败后的等待时间：Backoff*2^(n-1)，不超过MaxBackoff，再随机取后一半，避免一起重试
+func backoff(attempt int) time.Duration {
+	d := options.MaxBackoff
+	if attempt < 32 {
+		if b := options.Backoff << (attempt - 1); b > 0 && b < d {
+			d = b
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
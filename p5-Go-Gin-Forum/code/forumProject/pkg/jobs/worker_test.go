@@ -0,0 +1,284 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker 内存中的队列，Schedule 不等到期直接放回待执行队列，重试不用等待
+type fakeBroker struct {
+	ready chan *Delivery
+
+	mu        sync.Mutex
+	seq       int
+	acked     []string
+	scheduled []*Job
+	buried    []*Job
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{ready: make(chan *Delivery, 16)}
+}
+
+func (f *fakeBroker) Push(ctx context.Context, job *Job) error {
+	f.mu.Lock()
+	f.seq++
+	d := &Delivery{MessageID: strconv.Itoa(f.seq), Job: job}
+	f.mu.Unlock()
+	f.ready <- d
+	return nil
+}
+
+func (f *fakeBroker) Schedule(ctx context.Context, job *Job, at time.Time) error {
+	f.mu.Lock()
+	cp := *job
+	f.scheduled = append(f.scheduled, &cp)
+	f.mu.Unlock()
+	return f.Push(ctx, job)
+}
+
+func (f *fakeBroker) PromoteDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeBroker) Fetch(ctx context.Context, consumer string, minIdle, block time.Duration) (*Delivery, error) {
+	select {
+	case d := <-f.ready:
+		return d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(block):
+		return nil, nil
+	}
+}
+
+func (f *fakeBroker) Ack(ctx context.Context, d *Delivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, d.MessageID)
+	return nil
+}
+
+func (f *fakeBroker) Bury(ctx context.Context, job *Job) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := *job
+	f.buried = append(f.buried, &cp)
+	return nil
+}
+
+// counts 返回确认、重试和进入死信队列的次数
+func (f *fakeBroker) counts() (acked, scheduled, buried int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.acked), len(f.scheduled), len(f.buried)
+}
+
+// startTestPool 用 fakeBroker 启动一个worker，测试结束时停止并恢复全局状态
+func startTestPool(t *testing.T, opts Options) *fakeBroker {
+	t.Helper()
+	b := newFakeBroker()
+	oldBroker, oldOptions, oldPool := broker, options, pool
+	opts.Workers = 1
+	Init(b, opts)
+	Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = Shutdown(ctx)
+		broker, options, pool = oldBroker, oldOptions, oldPool
+	})
+	return b
+}
+
+// registerTest 注册只在当前测试中使用的处理函数
+func registerTest(t *testing.T, jobType string, fn func(ctx context.Context, payload string) error) {
+	t.Helper()
+	Register(jobType, fn)
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(handlers, jobType)
+		mu.Unlock()
+	})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func push(t *testing.T, b *fakeBroker, jobType string) {
+	t.Helper()
+	if err := b.Push(context.Background(), &Job{ID: "1", Type: jobType, Payload: []byte(`"x"`)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuryAfterMaxAttempts(t *testing.T) {
+	var callsMu sync.Mutex
+	calls := 0
+	registerTest(t, "test_always_fail", func(ctx context.Context, payload string) error {
+		callsMu.Lock()
+		defer callsMu.Unlock()
+		calls++
+		return errors.New("boom")
+	})
+	b := startTestPool(t, Options{MaxAttempts: 3})
+	push(t, b, "test_always_fail")
+
+	waitFor(t, "job buried", func() bool {
+		_, _, buried := b.counts()
+		return buried == 1
+	})
+	// 每次失败都要确认，重试靠 Schedule 重新投递
+	waitFor(t, "all attempts acked", func() bool {
+		acked, _, _ := b.counts()
+		return acked == 3
+	})
+	acked, scheduled, buried := b.counts()
+	callsMu.Lock()
+	defer callsMu.Unlock()
+	if calls != 3 || scheduled != 2 || buried != 1 || acked != 3 {
+		t.Fatalf("calls=%d scheduled=%d buried=%d acked=%d, want 3 2 1 3", calls, scheduled, buried, acked)
+	}
+	for i, job := range b.scheduled {
+		if job.Attempt != i+1 {
+			t.Errorf("retry %d has attempt %d, want %d", i, job.Attempt, i+1)
+		}
+	}
+	if job := b.buried[0]; job.Attempt != 3 || job.LastError != "boom" {
+		t.Errorf("buried job attempt=%d last_error=%q, want 3 %q", job.Attempt, job.LastError, "boom")
+	}
+}
+
+func TestAckOnSuccessAfterRetry(t *testing.T) {
+	var callsMu sync.Mutex
+	calls := 0
+	registerTest(t, "test_flaky", func(ctx context.Context, payload string) error {
+		callsMu.Lock()
+		defer callsMu.Unlock()
+		calls++
+		if calls < 2 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	b := startTestPool(t, Options{MaxAttempts: 3})
+	push(t, b, "test_flaky")
+
+	waitFor(t, "job acked", func() bool {
+		acked, _, _ := b.counts()
+		return acked == 2
+	})
+	acked, scheduled, buried := b.counts()
+	if scheduled != 1 || buried != 0 {
+		t.Fatalf("scheduled=%d buried=%d acked=%d, want 1 0 2", scheduled, buried, acked)
+	}
+}
+
+func TestPanicCountsAsFailure(t *testing.T) {
+	registerTest(t, "test_panic", func(ctx context.Context, payload string) error {
+		panic("bad payload")
+	})
+	b := startTestPool(t, Options{MaxAttempts: 1})
+	push(t, b, "test_panic")
+
+	waitFor(t, "job buried", func() bool {
+		_, _, buried := b.counts()
+		return buried == 1
+	})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if got := b.buried[0].LastError; got != "panic: bad payload" {
+		t.Errorf("last_error = %q", got)
+	}
+}
+
+func TestShutdownWaitsForInflightJob(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	registerTest(t, "test_slow", func(ctx context.Context, payload string) error {
+		close(started)
+		<-release
+		return nil
+	})
+	b := startTestPool(t, Options{})
+	push(t, b, "test_slow")
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- Shutdown(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v before the job finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return after the job finished")
+	}
+	if acked, _, _ := b.counts(); acked != 1 {
+		t.Errorf("acked = %d, want 1", acked)
+	}
+}
+
+func TestShutdownCancelsJobAfterDeadline(t *testing.T) {
+	started := make(chan struct{})
+	registerTest(t, "test_stuck", func(ctx context.Context, payload string) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	b := startTestPool(t, Options{MaxAttempts: 1})
+	push(t, b, "test_stuck")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown err = %v, want deadline exceeded", err)
+	}
+	// 被取消的任务按失败处理，收尾时 worker 已经全部退出
+	if acked, _, buried := b.counts(); acked != 1 || buried != 1 {
+		t.Errorf("acked=%d buried=%d, want 1 1", acked, buried)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	old := options
+	t.Cleanup(func() { options = old })
+	options = Options{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 10 * time.Second}, // 16s 超过上限
+		{64, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := backoff(tt.attempt); d < tt.base/2 || d > tt.base {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", tt.attempt, d, tt.base/2, tt.base)
+			}
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	tickInterval   = time.Second      // 检查任务是否到期的间隔
+	storeTimeout   = 3 * time.Second  // 选举、记录等redis操作的超时
+	defaultLockTTL = 15 * time.Second // 默认的 LockTTL
+	defaultHistory = 50               // 默认每个任务保留的执行记录数
+)
+
+// Options 调度参数，零值使用默认值
+type Options struct {
+	LockTTL     time.Duration // leader锁的有效期，每 LockTTL/3 续约一次，续约失败时在过期之前退下
+	HistorySize int
+}
+
+var (
+	options Options
+	store   Store
+	runner  *loop
+)
+
+// term 一个任期：成为leader时开始，失去leader时取消ctx，让正在执行的任务尽快退出
+type term struct {
+	token   int64
+	renewed time.Time // 上一次成功续约的时间
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+type loop struct {
+	holder  string
+	ctx     context.Context // Stop 时取消，停止调度
+	stop    context.CancelFunc
+	done    chan struct{}
+	runs    sync.WaitGroup // 正在执行的任务
+	current atomic.Pointer[term]
+}
+
+// Init 设置存储和调度参数
+func Init(s Store, opts Options) {
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = defaultLockTTL
+	}
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = defaultHistory
+	}
+	store, options = s, opts
+}
+
+// Start 开始选举和调度，每个实例都要启动，只有leader执行任务
+func Start() {
+	if store == nil {
+		return
+	}
+	host, _ := os.Hostname()
+	l := &loop{holder: fmt.Sprintf("%s-%d", host, os.Getpid()), done: make(chan struct{})}
+	l.ctx, l.stop = context.WithCancel(context.Background())
+	go l.run()
+	runner = l
+	zap.L().Info("scheduler started", zap.String("holder", l.holder), zap.Int("tasks", len(sortedTasks())))
+}
+
+// IsLeader 当前实例是否是leader
+func IsLeader() bool {
+	l := runner
+	return l != nil && l.current.Load() != nil
+}
+
+// Stop 停止调度并等待正在执行的任务完成，ctx到期后取消它们并释放leader锁，让其他实例尽快接手
+// 取消后不再等待，不理会ctx的任务会继续执行到进程退出
+func Stop(ctx context.Context) error {
+	l := runner
+	if l == nil {
+		return nil
+	}
+	l.stop()
+	<-l.done
+
+	finished := make(chan struct{})
+	go func() {
+		l.runs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		l.resign()
+		zap.L().Info("scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		l.resign()
+		return fmt.Errorf("scheduler: running tasks cancelled: %w", ctx.Err())
+	}
+}
+
+func (l *loop) run() {
+	defer close(l.done)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	renewEvery := renewInterval()
+	var lastElect time.Time
+	for {
+		now := time.Now()
+		if now.Sub(lastElect) >= renewEvery {
+			l.elect()
+			lastElect = now
+		}
+		l.tick(now)
+
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// elect 是leader时续约，否则尝试成为leader
+func (l *loop) elect() {
+	ctx, cancel := context.WithTimeout(l.ctx, storeTimeout)
+	defer cancel()
+
+	// 锁的有效期从redis收到命令时开始算，用发出命令前的时间，只会算早不会算晚
+	start := time.Now()
+	if t := l.current.Load(); t != nil {
+		ok, err := store.Renew(ctx, l.holder, t.token, options.LockTTL)
+		switch {
+		case err == nil && ok:
+			t.renewed = start
+		case err == nil:
+			zap.L().Warn("scheduler: lost leadership", zap.Int64("token", t.token))
+			l.current.Store(nil)
+			t.cancel()
+		case !t.canWait(time.Now()):
+			// 一直连不上redis，等到下一次续约时锁已经过期，可能已经有新的leader，要在过期之前退下
+			zap.L().Warn("scheduler: leader lock expires before next renew, step down", zap.Int64("token", t.token), zap.Error(err))
+			l.current.Store(nil)
+			t.cancel()
+		default:
+			// 还在有效期内，下次再续
+			zap.L().Warn("scheduler: renew leader lock failed", zap.Error(err))
+		}
+		return
+	}
+
+	token, err := store.Acquire(ctx, l.holder, options.LockTTL)
+	if err != nil {
+		if l.ctx.Err() == nil {
+			zap.L().Warn("scheduler: acquire leader lock failed", zap.Error(err))
+		}
+		return
+	}
+	if token == 0 {
+		return
+	}
+	t := &term{token: token, renewed: start}
+	t.ctx, t.cancel = context.WithCancel(context.WithValue(context.Background(), tokenKey{}, token))
+	l.current.Store(t)
+	zap.L().Info("scheduler: became leader", zap.Int64("token", token))
+}
+
+// renewInterval 续约的间隔
+func renewInterval() time.Duration {
+	return options.LockTTL / 3
+}
+
+// canWait 续约失败后能否等到下一次续约：下一次最晚在 renewInterval+tickInterval 之后发出，再等 storeTimeout 才有结果
+func (t *term) canWait(now time.Time) bool {
+	next := now.Add(renewInterval() + tickInterval + storeTimeout)
+	return next.Before(t.renewed.Add(options.LockTTL))
+}
+
+// resign 放弃leader
+func (l *loop) resign() {
+	t := l.current.Swap(nil)
+	if t == nil {
+		return
+	}
+	t.cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := store.Release(ctx, l.holder, t.token); err != nil {
+		zap.L().Warn("scheduler: release leader lock failed", zap.Error(err))
+	}
+}
+
+// tick 计算到期的任务，所有实例都会推进下一次执行时间，这样切换leader后不会补跑已经错过的
+func (l *loop) tick(now time.Time) {
+	t := l.current.Load()
+	for _, tk := range sortedTasks() {
+		tk.mu.Lock()
+		due := !tk.next.IsZero() && !now.Before(tk.next)
+		if tk.next.IsZero() || due {
+			tk.next = tk.schedule.Next(now)
+		}
+		tk.mu.Unlock()
+		if due && t != nil {
+			l.start(t, tk, TriggerSchedule)
+		}
+	}
+	if t == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(l.ctx, storeTimeout)
+	defer cancel()
+	names, err := store.TakeRequests(ctx)
+	if err != nil {
+		zap.L().Warn("scheduler: take manual runs failed", zap.Error(err))
+		return
+	}
+	for _, name := range names {
+		if tk, ok := lookup(name); ok {
+			l.start(t, tk, TriggerManual)
+		}
+	}
+}
+
+// start 在新的goroutine中执行任务，上一次还没执行完时跳过；手动触发被跳过时也写一条执行记录，方便在后台看到
+func (l *loop) start(t *term, tk *task, trigger string) {
+	run := &Run{Task: tk.name, Trigger: trigger, Holder: l.holder, Token: t.token, StartedAt: time.Now()}
+	tk.mu.Lock()
+	if tk.running {
+		tk.mu.Unlock()
+		zap.L().Warn("scheduler: task is still running, skip", zap.String("task", tk.name), zap.String("trigger", trigger))
+		if trigger == TriggerManual {
+			run.Error = "skipped: previous run is still running"
+			l.record(run)
+		}
+		return
+	}
+	tk.running = true
+	tk.mu.Unlock()
+
+	l.runs.Add(1)
+	go func() {
+		defer l.runs.Done()
+		err := execute(t.ctx, tk)
+		run.Duration = time.Since(run.StartedAt).Milliseconds()
+
+		tk.mu.Lock()
+		tk.running = false
+		tk.mu.Unlock()
+
+		log := zap.L().With(zap.String("task", tk.name), zap.String("trigger", trigger), zap.Int64("cost_ms", run.Duration))
+		if err != nil {
+			run.Error = err.Error()
+			log.Error("scheduled task failed", zap.Error(err))
+		} else {
+			log.Info("scheduled task done")
+		}
+		l.record(run)
+	}()
+}
+
+func (l *loop) record(run *Run) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := store.Record(ctx, run, options.HistorySize); err != nil {
+		zap.L().Warn("scheduler: record history failed", zap.String("task", run.Task), zap.Error(err))
+	}
+}
+
+// execute 执行任务，panic时当作失败
+func execute(ctx context.Context, tk *task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return tk.fn(ctx)
+}
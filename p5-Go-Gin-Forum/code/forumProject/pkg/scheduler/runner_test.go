@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore 只实现选举，记录释放的token
+type fakeStore struct {
+	mu       sync.Mutex
+	renewErr error
+	released []int64
+}
+
+func (f *fakeStore) setRenewErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.renewErr = err
+}
+
+func (f *fakeStore) Acquire(ctx context.Context, holder string, ttl time.Duration) (int64, error) {
+	return 1, nil
+}
+
+func (f *fakeStore) Renew(ctx context.Context, holder string, token int64, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.renewErr == nil, f.renewErr
+}
+
+func (f *fakeStore) Release(ctx context.Context, holder string, token int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released = append(f.released, token)
+	return nil
+}
+
+func (f *fakeStore) Fence(ctx context.Context) (int64, error)                     { return 1, nil }
+func (f *fakeStore) Record(ctx context.Context, run *Run, keep int) error         { return nil }
+func (f *fakeStore) History(ctx context.Context, t string, n int) ([]*Run, error) { return nil, nil }
+func (f *fakeStore) RequestRun(ctx context.Context, task string) error            { return nil }
+func (f *fakeStore) TakeRequests(ctx context.Context) ([]string, error)           { return nil, nil }
+
+func newTestLoop(t *testing.T) (*loop, *fakeStore) {
+	t.Helper()
+	s := &fakeStore{}
+	oldStore, oldOptions, oldRunner := store, options, runner
+	Init(s, Options{LockTTL: 15 * time.Second})
+	l := &loop{holder: "test", done: make(chan struct{})}
+	l.ctx, l.stop = context.WithCancel(context.Background())
+	runner = l
+	t.Cleanup(func() {
+		l.stop()
+		store, options, runner = oldStore, oldOptions, oldRunner
+	})
+	return l, s
+}
+
+func TestElectStepsDownBeforeExpiry(t *testing.T) {
+	l, s := newTestLoop(t)
+	l.elect()
+	term := l.current.Load()
+	if term == nil {
+		t.Fatal("should become leader")
+	}
+
+	// 刚续约过，失败一次还能等到下一次续约
+	s.setRenewErr(errors.New("redis down"))
+	l.elect()
+	if !IsLeader() {
+		t.Fatal("one failed renew right after acquire should not step down")
+	}
+
+	// 距上次续约成功已经过了两个续约间隔，下一次续约的结果出来时锁已经过期
+	term.renewed = time.Now().Add(-2 * renewInterval())
+	l.elect()
+	if IsLeader() {
+		t.Fatal("should step down before the lock expires")
+	}
+	if term.ctx.Err() == nil {
+		t.Error("running tasks of the old term should be cancelled")
+	}
+}
+
+func TestStopDoesNotWaitForStuckTasks(t *testing.T) {
+	l, s := newTestLoop(t)
+	close(l.done)
+	l.elect()
+
+	// 不理会ctx的任务
+	block := make(chan struct{})
+	t.Cleanup(func() {
+		close(block)
+		l.runs.Wait()
+	})
+	l.start(l.current.Load(), &task{name: "stuck", fn: func(ctx context.Context) error {
+		<-block
+		return nil
+	}}, TriggerManual)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	returned := make(chan error, 1)
+	go func() { returned <- Stop(ctx) }()
+	select {
+	case err := <-returned:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Stop() = %v, want deadline exceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop waited for a task that ignores ctx")
+	}
+	if IsLeader() || len(s.released) != 1 {
+		t.Errorf("leader lock should be released, released %v", s.released)
+	}
+}
@@ -0,0 +1,189 @@
+// scheduler 定时任务：每个实例都注册同样的任务，但只有抢到leader锁的实例执行，保证整个集群每次只执行一遍
+// 每次成为leader会拿到一个递增的fencing token，任务在写数据前可以用 CheckFence 确认自己没有被新leader取代
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	// ErrNotInitialized 还没有调用 Init
+	ErrNotInitialized = errors.New("scheduler: not initialized")
+	// ErrUnknownTask 没有注册这个任务
+	ErrUnknownTask = errors.New("scheduler: unknown task")
+	// ErrFenced 已经有新的leader，当前执行应该放弃写入
+	ErrFenced = errors.New("scheduler: fenced by a newer leader")
+)
+
+// 触发方式
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Run 任务的一次执行记录
+type Run struct {
+	Task      string    `json:"task"`
+	Trigger   string    `json:"trigger"`
+	Holder    string    `json:"holder"` // 执行的实例
+	Token     int64     `json:"token"`  // 执行时的fencing token
+	StartedAt time.Time `json:"started_at"`
+	Duration  int64     `json:"duration_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Store leader锁和执行记录的存储，由 dao/redis.SchedulerStore 实现
+type Store interface {
+	// Acquire 尝试成为leader，成功时返回新的fencing token，锁被其他实例持有时返回0
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (int64, error)
+	// Renew 续约，锁已过期或被其他实例持有时返回false
+	Renew(ctx context.Context, holder string, token int64, ttl time.Duration) (bool, error)
+	// Release 释放锁
+	Release(ctx context.Context, holder string, token int64) error
+	// Fence 最新发出的fencing token
+	Fence(ctx context.Context) (int64, error)
+	// Record 保存一次执行记录，每个任务只保留最近的keep条
+	Record(ctx context.Context, run *Run, keep int) error
+	// History 最近的执行记录，最新的在前
+	History(ctx context.Context, task string, limit int) ([]*Run, error)
+	// RequestRun 请求leader执行一次任务
+	RequestRun(ctx context.Context, task string) error
+	// TakeRequests 取出所有待执行的请求
+	TakeRequests(ctx context.Context) ([]string, error)
+}
+
+type task struct {
+	name     string
+	spec     string
+	schedule cron.Schedule
+	fn       func(ctx context.Context) error
+
+	mu      sync.Mutex
+	next    time.Time
+	running bool
+}
+
+var (
+	mu    sync.RWMutex
+	tasks = map[string]*task{}
+)
+
+// Register 注册一个定时任务，应在 Start 之前调用
+// spec 是标准的5段cron表达式，也支持 @hourly、@every 10m 这样的写法；表达式错误或名字重复时panic
+func Register(name, spec string, fn func(ctx context.Context) error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		panic(fmt.Sprintf("scheduler: invalid spec %q for %s: %v", spec, name, err))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := tasks[name]; ok {
+		panic("scheduler: duplicate task " + name)
+	}
+	tasks[name] = &task{name: name, spec: spec, schedule: schedule, fn: fn}
+}
+
+func lookup(name string) (*task, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := tasks[name]
+	return t, ok
+}
+
+// sortedTasks 按名字排序的所有任务
+func sortedTasks() []*task {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]*task, 0, len(tasks))
+	for _, t := range tasks {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+// TaskInfo 任务的状态
+type TaskInfo struct {
+	Name    string    `json:"name"`
+	Spec    string    `json:"spec"`
+	Next    time.Time `json:"next"`
+	Running bool      `json:"running"` // 只反映当前实例
+	LastRun *Run      `json:"last_run,omitempty"`
+}
+
+// Tasks 所有任务的状态，上一次执行的记录从存储中读取，所以在非leader实例上也能看到
+func Tasks(ctx context.Context) ([]TaskInfo, error) {
+	if store == nil {
+		return nil, ErrNotInitialized
+	}
+	list := sortedTasks()
+	infos := make([]TaskInfo, 0, len(list))
+	for _, t := range list {
+		t.mu.Lock()
+		info := TaskInfo{Name: t.name, Spec: t.spec, Next: t.next, Running: t.running}
+		t.mu.Unlock()
+		runs, err := store.History(ctx, t.name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			info.LastRun = runs[0]
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// History 任务最近的执行记录
+func History(ctx context.Context, name string, limit int) ([]*Run, error) {
+	if store == nil {
+		return nil, ErrNotInitialized
+	}
+	if _, ok := lookup(name); !ok {
+		return nil, ErrUnknownTask
+	}
+	return store.History(ctx, name, limit)
+}
+
+// Trigger 手动执行一次任务；为了保证只在一个实例上执行，请求交给leader在下一个tick执行
+func Trigger(ctx context.Context, name string) error {
+	if store == nil {
+		return ErrNotInitialized
+	}
+	if _, ok := lookup(name); !ok {
+		return ErrUnknownTask
+	}
+	return store.RequestRun(ctx, name)
+}
+
+type tokenKey struct{}
+
+// FencingToken 任务执行时的fencing token，不在任务中调用时返回0
+func FencingToken(ctx context.Context) int64 {
+	token, _ := ctx.Value(tokenKey{}).(int64)
+	return token
+}
+
+// CheckFence 确认当前执行的leader没有被取代，任务在写数据之前调用
+// 网络分区或进程长时间暂停时旧leader可能还在执行，这时返回 ErrFenced
+func CheckFence(ctx context.Context) error {
+	token := FencingToken(ctx)
+	if token == 0 || store == nil {
+		return ErrNotInitialized
+	}
+	latest, err := store.Fence(ctx)
+	if err != nil {
+		return err
+	}
+	if latest != token {
+		return ErrFenced
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package snowflake
+
+import "time"
+
+// IDInfo 从ID中解析出来的信息
+type IDInfo struct {
+	ID        uint64    `json:"id,string"`
+	Time      time.Time `json:"time"` // 生成时间，精度10ms
+	MachineID uint16    `json:"machine_id"`
+	Sequence  uint16    `json:"sequence"`
+	Base62    string    `json:"base62"`
+	Base58    string    `json:"base58"`
+}
+
+// Decode 解析ID的生成时间、机器ID和序列号，需要先Init以确定开始时间
+func Decode(id uint64) IDInfo {
+	return IDInfo{
+		ID:        id,
+		Time:      startTime.Add(time.Duration(int64(id>>(BitLenSequence+BitLenMachineID)) * timeUnit)),
+		MachineID: uint16(id & (1<<BitLenMachineID - 1)),
+		Sequence:  uint16(id >> BitLenMachineID & uint64(maskSequence)),
+		Base62:    EncodeBase62(id),
+		Base58:    EncodeBase58(id),
+	}
+}
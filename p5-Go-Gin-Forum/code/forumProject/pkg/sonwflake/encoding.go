@@ -0,0 +1,78 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 用于对外URL的短编码，base58去掉了容易混淆的 0、O、I、l
+var (
+	base62 = newEncoding("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+	base58 = newEncoding("123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+)
+
+var ErrInvalidEncoding = errors.New("invalid encoded id")
+
+type encoding struct {
+	alphabet string
+	base     uint64
+	index    [256]int
+}
+
+func newEncoding(alphabet string) *encoding {
+	e := &encoding{alphabet: alphabet, base: uint64(len(alphabet))}
+	for i := range e.index {
+		e.index[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.index[alphabet[i]] = i
+	}
+	return e
+}
+
+func (e *encoding) encode(id uint64) string {
+	if id == 0 {
+		return e.alphabet[:1]
+	}
+	var buf [16]byte // 58^11 > 2^64，16位足够
+	i := len(buf)
+	for id > 0 {
+		i--
+		buf[i] = e.alphabet[id%e.base]
+		id /= e.base
+	}
+	return string(buf[i:])
+}
+
+func (e *encoding) decode(s string) (uint64, error) {
+	if s == "" {
+		return 0, ErrInvalidEncoding
+	}
+	var id uint64
+	for i := 0; i < len(s); i++ {
+		d := e.index[s[i]]
+		if d < 0 {
+			return 0, fmt.Errorf("%w: unexpected %q", ErrInvalidEncoding, s[i])
+		}
+		// 溢出检查
+		if id > (math.MaxUint64-uint64(d))/e.base {
+			return 0, fmt.Errorf("%w: overflow", ErrInvalidEncoding)
+		}
+		id = id*e.base + uint64(d)
+	}
+	return id, nil
+}
+
+// EncodeBase62 把ID编码成base62字符串
+func EncodeBase62(id uint64) string { return base62.encode(id) }
+
+// DecodeBase62 把base62字符串还原成ID
+func DecodeBase62(s string) (uint64, error) { return base62.decode(strings.TrimSpace(s)) }
+
+// EncodeBase58 把ID编码成base58字符串
+func EncodeBase58(id uint64) string { return base58.encode(id) }
+
+// DecodeBase58 把base58字符串还原成ID
+func DecodeBase58(s string) (uint64, error) { return base58.decode(strings.TrimSpace(s)) }
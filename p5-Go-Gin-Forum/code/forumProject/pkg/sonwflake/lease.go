@@ -0,0 +1,188 @@
+package snowflake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrLeaseLost 机器ID租约丢失（过期后被其他实例占用）或快要过期还没有续上，继续生成ID可能会和其他实例重复
+var ErrLeaseLost = errors.New("snowflake machine id lease lost")
+
+// Leaser 机器ID租约的存储，由 dao/redis.MachineIDLeaser 实现
+type Leaser interface {
+	Acquire(ctx context.Context, id uint16, token string, ttl time.Duration) (bool, error)
+	Renew(ctx context.Context, id uint16, token string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, id uint16, token string) error
+}
+
+type lease struct {
+	leaser Leaser
+	id     uint16
+	token  string
+	ttl    time.Duration
+	lost   atomic.Bool  // 确认被其他实例占用，不会再恢复
+	valid  atomic.Int64 // 在这个时间（UnixNano）之前可以生成ID，每次续约成功后延长
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+var current *lease
+
+// InitLease 从 leaser 租用一个没有被占用的机器ID并初始化雪花算法
+// 优先尝试 preferred，再依次尝试 0~maxID；全部被占用时返回错误，调用方应终止启动
+func InitLease(leaser Leaser, preferred, maxID uint16, ttl time.Duration) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	id, err := acquire(leaser, token, preferred, maxID, ttl)
+	if err != nil {
+		return err
+	}
+
+	l := &lease{
+		leaser: leaser,
+		id:     id,
+		token:  token,
+		ttl:    ttl,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	l.extend(start)
+	if err = Init(id); err != nil {
+		_ = leaser.Release(context.Background(), id, token)
+		return err
+	}
+	current = l
+	go l.heartbeat()
+
+	zap.L().Info("snowflake machine id leased", zap.Uint16("machine_id", id))
+	return nil
+}
+
+// releaseLease 停止心跳并释放租约，使用静态机器ID时什么也不做
+func releaseLease() {
+	if current == nil {
+		return
+	}
+	close(current.stop)
+	<-current.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := current.leaser.Release(ctx, current.id, current.token); err != nil {
+		zap.L().Error("release snowflake machine id failed", zap.Uint16("machine_id", current.id), zap.Error(err))
+	}
+}
+
+// leaseLost 当前租约是否已经丢失或者快要过期
+func leaseLost() bool {
+	return current != nil && !current.usable(time.Now())
+}
+
+// renewInterval 续约的间隔
+func (l *lease) renewInterval() time.Duration {
+	return l.ttl / 3
+}
+
+// extend 续约成功后调用，start 是发出续约请求的时间，key的过期时间不会早于 start+ttl
+// 留出一个续约间隔的余量，保证在key过期、其他实例能够占用之前就停止生成ID
+func (l *lease) extend(start time.Time) {
+	l.valid.Store(start.Add(l.ttl - l.renewInterval()).UnixNano())
+}
+
+// usable 此刻能否生成ID
+func (l *lease) usable(now time.Time) bool {
+	return !l.lost.Load() && now.UnixNano() < l.valid.Load()
+}
+
+func acquire(leaser Leaser, token string, preferred, maxID uint16, ttl time.Duration) (uint16, error) {
+	if preferred > maxID {
+		preferred = 0
+	}
+	n := int(maxID) + 1
+	for i := 0; i < n; i++ {
+		id := uint16((int(preferred) + i) % n)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		ok, err := leaser.Acquire(ctx, id, token, ttl)
+		cancel()
+		if err != nil {
+			return 0, fmt.Errorf("lease snowflake machine id failed: %w", err)
+		}
+		if ok {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no free snowflake machine id in [0, %d]", maxID)
+}
+
+// heartbeat 每 ttl/3 续约一次；续约一直失败时 usable 会在key过期之前返回false，暂停生成ID，
+// 之后续约或重新占用成功就恢复；租约已经被别人占用时标记为丢失，不再恢复
+func (l *lease) heartbeat() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.renewInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), l.renewInterval())
+		ok, err := l.leaser.Renew(ctx, l.id, l.token, l.ttl)
+		if err == nil && !ok {
+			// key已经过期：没人占用的话重新占上，否则说明被别的实例拿走了
+			ok, err = l.leaser.Acquire(ctx, l.id, l.token, l.ttl)
+			if err == nil && !ok {
+				l.markLost(errors.New("taken by another instance"))
+				cancel()
+				return
+			}
+		}
+		cancel()
+
+		if err != nil {
+			if l.usable(time.Now()) {
+				zap.L().Warn("renew snowflake machine id failed", zap.Uint16("machine_id", l.id), zap.Error(err))
+			} else {
+				zap.L().Error("renew snowflake machine id failed, stop generating ids until renewed",
+					zap.Uint16("machine_id", l.id), zap.Error(err))
+			}
+			continue
+		}
+		if !l.usable(start) {
+			zap.L().Info("snowflake machine id renewed, resume generating ids", zap.Uint16("machine_id", l.id))
+		}
+		l.extend(start)
+	}
+}
+
+func (l *lease) markLost(reason error) {
+	l.lost.Store(true)
+	zap.L().Error("snowflake machine id lease lost, stop generating ids",
+		zap.Uint16("machine_id", l.id), zap.Error(reason))
+}
+
+// newToken 租约持有者标识：主机名-进程号-随机数
+func newToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,98 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLeaser 可以随时切换续约结果
+type fakeLeaser struct {
+	mu       sync.Mutex
+	renewErr error
+	renewOK  bool
+	takenBy  bool // key已经被其他实例占用
+}
+
+func (f *fakeLeaser) set(renewOK bool, renewErr error, taken bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.renewOK, f.renewErr, f.takenBy = renewOK, renewErr, taken
+}
+
+func (f *fakeLeaser) Acquire(ctx context.Context, id uint16, token string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.takenBy, nil
+}
+
+func (f *fakeLeaser) Renew(ctx context.Context, id uint16, token string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.renewOK, f.renewErr
+}
+
+func (f *fakeLeaser) Release(ctx context.Context, id uint16, token string) error { return nil }
+
+func startTestLease(t *testing.T, leaser Leaser, ttl time.Duration) *lease {
+	t.Helper()
+	l := &lease{leaser: leaser, id: 1, token: "test", ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+	l.extend(time.Now())
+	old := current
+	current = l
+	go l.heartbeat()
+	t.Cleanup(func() {
+		close(l.stop)
+		<-l.done
+		current = old
+	})
+	return l
+}
+
+func waitFor(t *testing.T, within time.Duration, cond func() bool, what string) {
+	t.Helper()
+	deadline := time.Now().Add(within)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLeaseStopsBeforeExpiry(t *testing.T) {
+	const ttl = 600 * time.Millisecond
+	leaser := &fakeLeaser{renewErr: errors.New("redis down")}
+	start := time.Now()
+	startTestLease(t, leaser, ttl)
+
+	if leaseLost() {
+		t.Fatal("lease should be usable right after acquire")
+	}
+	// 续约一直失败，必须在key过期之前停止生成ID
+	waitFor(t, ttl, leaseLost, "lease to stop")
+	if elapsed := time.Since(start); elapsed >= ttl {
+		t.Fatalf("stopped after %s, key already expired at %s", elapsed, ttl)
+	}
+
+	// 续约恢复后继续生成ID
+	leaser.set(true, nil, false)
+	waitFor(t, ttl, func() bool { return !leaseLost() }, "lease to resume")
+}
+
+func TestLeaseTakenByOthers(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+	leaser := &fakeLeaser{}
+	leaser.set(false, nil, true)
+	l := startTestLease(t, leaser, ttl)
+
+	waitFor(t, ttl, l.lost.Load, "lease to be marked lost")
+	// 被占用之后即使续约恢复也不能再生成ID
+	leaser.set(true, nil, false)
+	time.Sleep(ttl)
+	if !leaseLost() {
+		t.Fatal("lease taken by another instance must not resume")
+	}
+}
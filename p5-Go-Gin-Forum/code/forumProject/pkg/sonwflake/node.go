@@ -0,0 +1,197 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ID的位布局和sonyflake保持一致，已经生成过的ID仍然可以用 Decode 解析：
+// 39位时间（单位10ms）+ 8位序列号 + 16位机器ID
+const (
+	BitLenTime      = 39
+	BitLenSequence  = 8
+	BitLenMachineID = 63 - BitLenTime - BitLenSequence
+
+	timeUnit     = int64(10 * time.Millisecond)
+	maskSequence = uint16(1<<BitLenSequence - 1)
+)
+
+var (
+	// ErrOverTimeLimit 时间位用完了（开始时间约174年之后）
+	ErrOverTimeLimit = errors.New("snowflake: over the time limit")
+	// ErrClockRollback 系统时钟回拨，且策略为fail或者等待时间超过了上限
+	ErrClockRollback = errors.New("snowflake: clock moved backwards")
+)
+
+// RollbackPolicy 时钟回拨时的处理方式
+type RollbackPolicy string
+
+const (
+	// RollbackWait 等待时钟追上上一次生成ID的时间，超过 MaxRollbackWait 则返回错误
+	RollbackWait RollbackPolicy = "wait"
+	// RollbackFail 立即返回 ErrClockRollback
+	RollbackFail RollbackPolicy = "fail"
+)
+
+// Clock 时间来源，测试时可以注入可控的时钟
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// Options 创建Node的参数
+type Options struct {
+	StartTime       time.Time
+	MachineID       uint16
+	Rollback        RollbackPolicy // 默认 RollbackWait
+	MaxRollbackWait time.Duration  // 默认1s
+	Clock           Clock          // 默认使用系统时钟
+}
+
+// Node 一个ID生成节点，并发安全
+type Node struct {
+	mu        sync.Mutex
+	opts      Options
+	startTime int64 // 开始时间，单位10ms
+	elapsed   int64 // 上一次生成ID的时间，相对开始时间，单位10ms
+	sequence  uint16
+}
+
+// NewNode 创建ID生成节点
+func NewNode(opts Options) (*Node, error) {
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	if opts.Rollback == "" {
+		opts.Rollback = RollbackWait
+	}
+	if opts.Rollback != RollbackWait && opts.Rollback != RollbackFail {
+		return nil, fmt.Errorf("snowflake: unknown rollback policy %q", opts.Rollback)
+	}
+	if opts.MaxRollbackWait <= 0 {
+		opts.MaxRollbackWait = time.Second
+	}
+	if opts.StartTime.After(opts.Clock.Now()) {
+		return nil, fmt.Errorf("snowflake: start time %s is ahead of now", opts.StartTime)
+	}
+
+	return &Node{
+		opts:      opts,
+		startTime: toUnits(opts.StartTime),
+		// 第一次生成时序列号会回到0
+		sequence: maskSequence,
+	}, nil
+}
+
+// MachineID 当前节点的机器ID
+func (n *Node) MachineID() uint16 {
+	return n.opts.MachineID
+}
+
+// NextID 生成一个ID
+func (n *Node) NextID() (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	id, err := n.next()
+	if err == nil {
+		stats.generated.Add(1)
+	}
+	return id, err
+}
+
+// NextIDs 一次生成n个ID，只加一次锁，适合批量导入
+func (n *Node) NextIDs(count int) ([]uint64, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("snowflake: invalid count %d", count)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ids := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		id, err := n.next()
+		if err != nil {
+			// 已经生成的ID不会返回给调用方，不计入 generated
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	stats.generated.Add(uint64(count))
+	stats.batches.Add(1)
+	return ids, nil
+}
+
+// next 调用方需要持有锁
+func (n *Node) next() (uint64, error) {
+	current, err := n.current()
+	if err != nil {
+		return 0, err
+	}
+
+	if n.elapsed < current {
+		n.elapsed = current
+		n.sequence = 0
+	} else {
+		// 同一个10ms内，序列号递增；用完了就借用下一个时间单位并等到那个时刻
+		n.sequence = (n.sequence + 1) & maskSequence
+		if n.sequence == 0 {
+			n.elapsed++
+			stats.sequenceOverflows.Add(1)
+			n.opts.Clock.Sleep(n.sleepUntil(n.elapsed))
+		}
+	}
+
+	if n.elapsed >= 1<<BitLenTime {
+		return 0, ErrOverTimeLimit
+	}
+	return uint64(n.elapsed)<<(BitLenSequence+BitLenMachineID) |
+		uint64(n.sequence)<<BitLenMachineID |
+		uint64(n.opts.MachineID), nil
+}
+
+// current 返回当前时间，发现时钟回拨时按策略等待或失败
+func (n *Node) current() (int64, error) {
+	current := n.elapsedNow()
+	if current >= n.elapsed {
+		return current, nil
+	}
+
+	stats.rollbacks.Add(1)
+	behind := time.Duration((n.elapsed - current) * timeUnit)
+	if n.opts.Rollback == RollbackFail || behind > n.opts.MaxRollbackWait {
+		stats.rollbackFailures.Add(1)
+		return 0, fmt.Errorf("%w by %s", ErrClockRollback, behind)
+	}
+
+	n.opts.Clock.Sleep(n.sleepUntil(n.elapsed))
+	stats.rollbackWaitNanos.Add(uint64(behind))
+	if current = n.elapsedNow(); current < n.elapsed {
+		// 等待之后时钟仍然落后，说明又回拨了
+		stats.rollbackFailures.Add(1)
+		return 0, ErrClockRollback
+	}
+	return current, nil
+}
+
+func (n *Node) elapsedNow() int64 {
+	return toUnits(n.opts.Clock.Now()) - n.startTime
+}
+
+// sleepUntil 到达指定时间单位还需要等待多久
+func (n *Node) sleepUntil(elapsed int64) time.Duration {
+	target := (n.startTime + elapsed) * timeUnit
+	return time.Duration(target - n.opts.Clock.Now().UTC().UnixNano())
+}
+
+func toUnits(t time.Time) int64 {
+	return t.UTC().UnixNano() / timeUnit
+}
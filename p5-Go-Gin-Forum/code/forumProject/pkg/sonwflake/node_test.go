@@ -0,0 +1,238 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动控制的时钟，Sleep 直接把时间往后拨
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	slept  time.Duration
+	sleeps int
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps++
+	if d > 0 {
+		c.now = c.now.Add(d)
+		c.slept += d
+	}
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestNode(t *testing.T, clock *fakeClock, policy RollbackPolicy, wait time.Duration) *Node {
+	t.Helper()
+	n, err := NewNode(Options{
+		StartTime:       clock.Now().Add(-time.Hour),
+		MachineID:       7,
+		Rollback:        policy,
+		MaxRollbackWait: wait,
+		Clock:           clock,
+	})
+	if err != nil {
+		t.Fatalf("NewNode: %v", err)
+	}
+	return n
+}
+
+func timePart(id uint64) uint64 {
+	return id >> (BitLenSequence + BitLenMachineID)
+}
+
+func TestRollbackWithinWait(t *testing.T) {
+	clock := newFakeClock()
+	n := newTestNode(t, clock, RollbackWait, 100*time.Millisecond)
+
+	first, err := n.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-50 * time.Millisecond)
+
+	second, err := n.NextID()
+	if err != nil {
+		t.Fatalf("rollback within MaxRollbackWait should wait, got %v", err)
+	}
+	if clock.slept < 50*time.Millisecond {
+		t.Errorf("slept %s, want at least 50ms", clock.slept)
+	}
+	if second <= first {
+		t.Errorf("id after rollback %d is not greater than %d", second, first)
+	}
+}
+
+func TestRollbackPastWait(t *testing.T) {
+	clock := newFakeClock()
+	n := newTestNode(t, clock, RollbackWait, 100*time.Millisecond)
+
+	if _, err := n.NextID(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-200 * time.Millisecond)
+
+	if _, err := n.NextID(); !errors.Is(err, ErrClockRollback) {
+		t.Fatalf("err = %v, want ErrClockRollback", err)
+	}
+	if clock.sleeps != 0 {
+		t.Errorf("should fail without waiting, slept %d times", clock.sleeps)
+	}
+}
+
+func TestRollbackFail(t *testing.T) {
+	clock := newFakeClock()
+	n := newTestNode(t, clock, RollbackFail, time.Second)
+
+	if _, err := n.NextID(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-10 * time.Millisecond)
+
+	if _, err := n.NextID(); !errors.Is(err, ErrClockRollback) {
+		t.Fatalf("err = %v, want ErrClockRollback", err)
+	}
+
+	// 时钟追上之后恢复正常
+	clock.Add(20 * time.Millisecond)
+	if _, err := n.NextID(); err != nil {
+		t.Fatalf("after clock caught up: %v", err)
+	}
+}
+
+func TestSequenceExhaustion(t *testing.T) {
+	clock := newFakeClock()
+	n := newTestNode(t, clock, RollbackWait, time.Second)
+
+	// 时钟不动，一个时间单位内的序列号全部用完
+	var last uint64
+	for i := 0; i <= int(maskSequence); i++ {
+		id, err := n.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && timePart(id) != timePart(last) {
+			t.Fatalf("id %d moved to the next time unit early", i)
+		}
+		last = id
+	}
+	if clock.sleeps != 0 {
+		t.Fatalf("slept before the sequence was exhausted")
+	}
+
+	id, err := n.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timePart(id) != timePart(last)+1 {
+		t.Errorf("time part = %d, want %d", timePart(id), timePart(last)+1)
+	}
+	if seq := id >> BitLenMachineID & uint64(maskSequence); seq != 0 {
+		t.Errorf("sequence = %d, want 0", seq)
+	}
+	// 借用了下一个时间单位，需要等到那个时刻
+	if clock.slept <= 0 || clock.slept > time.Duration(timeUnit) {
+		t.Errorf("slept %s, want (0, 10ms]", clock.slept)
+	}
+}
+
+func TestGetIDs(t *testing.T) {
+	clock := newFakeClock()
+	old := node
+	node = newTestNode(t, clock, RollbackWait, time.Second)
+	defer func() { node = old }()
+
+	const count = 1000
+	ids, err := GetIDs(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != count {
+		t.Fatalf("got %d ids, want %d", len(ids), count)
+	}
+	seen := make(map[uint64]bool, count)
+	for i, id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+		if i > 0 && id <= ids[i-1] {
+			t.Fatalf("ids[%d]=%d is not greater than ids[%d]=%d", i, id, i-1, ids[i-1])
+		}
+	}
+
+	if _, err := GetIDs(0); err == nil {
+		t.Error("GetIDs(0) should fail")
+	}
+}
+
+func TestNextIDsErrorNotCounted(t *testing.T) {
+	clock := newFakeClock()
+	n := newTestNode(t, clock, RollbackFail, time.Second)
+	if _, err := n.NextID(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-time.Second)
+
+	before := Stats().Generated
+	if _, err := n.NextIDs(10); err == nil {
+		t.Fatal("NextIDs should fail after rollback")
+	}
+	if got := Stats().Generated; got != before {
+		t.Errorf("generated changed from %d to %d on failure", before, got)
+	}
+}
+
+func TestPoolRefill(t *testing.T) {
+	clock := newFakeClock()
+	n := newTestNode(t, clock, RollbackWait, time.Second)
+
+	const size = 16
+	p := newPool(n, size)
+	defer p.close()
+
+	waitFull := func() {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for len(p.ids) < size {
+			if time.Now().After(deadline) {
+				t.Fatalf("pool has %d ids, want %d", len(p.ids), size)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	seen := make(map[uint64]bool)
+	for round := 0; round < 3; round++ {
+		waitFull()
+		for i := 0; i < size; i++ {
+			id, ok := p.get()
+			if !ok {
+				t.Fatalf("round %d: pool empty after %d gets", round, i)
+			}
+			if seen[id] {
+				t.Fatalf("duplicate id %d from pool", id)
+			}
+			seen[id] = true
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package snowflake
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pool 后台预先生成ID放进缓冲，热点路径上直接取用，不用和其他请求抢Node的锁
+type pool struct {
+	node *Node
+	ids  chan uint64
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newPool(node *Node, size int) *pool {
+	p := &pool{
+		node: node,
+		ids:  make(chan uint64, size),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *pool) run() {
+	defer close(p.done)
+
+	// 每批不超过一个时间单位内的序列号数，避免一次性借用太多未来的时间
+	batch := cap(p.ids)
+	if batch > int(maskSequence)+1 {
+		batch = int(maskSequence) + 1
+	}
+	for {
+		ids, err := p.node.NextIDs(batch)
+		if err != nil {
+			zap.L().Warn("snowflake pool refill failed", zap.Error(err))
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+		for _, id := range ids {
+			select {
+			case p.ids <- id:
+			case <-p.stop:
+				return
+			}
+		}
+	}
+}
+
+// get 缓冲池为空时返回false，由调用方现场生成
+func (p *pool) get() (uint64, bool) {
+	select {
+	case id := <-p.ids:
+		stats.poolHits.Add(1)
+		return id, true
+	default:
+		stats.poolMisses.Add(1)
+		return 0, false
+	}
+}
+
+func (p *pool) close() {
+	close(p.stop)
+	<-p.done
+}
@@ -1,50 +1,79 @@
 package snowflake
 
 import (
-	"fmt"
+	"errors"
 	settings2 "forumProject/settings"
 	"time"
-
-	sf "github.com/sony/sonyflake"
 )
 
 var (
-	sonyFlake     *sf.Sonyflake // 实例
-	sonyMachineID uint16        // 机器ID
+	node      *Node     // 实例
+	idPool    *pool     // 预生成ID的缓冲池，未开启时为nil
+	startTime time.Time // 开始时间，解析ID时用来还原生成时间
 )
 
-func getMachineID() (uint16, error) { // 返回全局定义的机器ID
-	return sonyMachineID, nil
-}
-
 // 需传入当前的机器ID
 func Init(machineId uint16) (err error) {
-	sonyMachineID = machineId
-	t, _ := time.Parse("2006-01-02", settings2.Conf.StartTime) // 初始化一个开始的时间
-	settings := sf.Settings{                                   // 生成全局配置
+	conf := settings2.Get()
+	t, _ := time.Parse(settings2.StartTimeLayout, conf.StartTime) // 初始化一个开始的时间
+
+	opts := Options{ // 生成节点配置
 		StartTime: t,
-		MachineID: getMachineID, // 指定机器ID
+		MachineID: machineId, // 指定机器ID
+	}
+	bufferSize := 0
+	if sc := conf.SnowflakeConfig; sc != nil {
+		opts.Rollback = RollbackPolicy(sc.Rollback)
+		opts.MaxRollbackWait = time.Duration(sc.MaxRollbackWait) * time.Millisecond
+		bufferSize = sc.BufferSize
+	}
+
+	n, err := NewNode(opts) // 用配置生成节点
+	if err != nil {
+		return
+	}
+	node, startTime = n, t
+	if bufferSize > 0 {
+		idPool = newPool(node, bufferSize)
 	}
-	sonyFlake = sf.NewSonyflake(settings) // 用配置生成sonyflake节点
 	return
 }
 
+// Close 停止缓冲池并释放机器ID租约
+func Close() {
+	if idPool != nil {
+		idPool.close()
+	}
+	releaseLease()
+}
+
 // GetID 返回生成的id值
-func GetID() (id uint64, err error) { // 拿到sonyflake节点生成id值
-	if sonyFlake == nil {
-		err = fmt.Errorf("snoy flake not inited")
+func GetID() (id uint64, err error) { // 拿到节点生成id值
+	if err = check(); err != nil {
 		return
 	}
+	if idPool != nil {
+		if id, ok := idPool.get(); ok {
+			return id, nil
+		}
+	}
+	return node.NextID()
+}
 
-	id, err = sonyFlake.NextID()
-	return
+// GetIDs 一次返回n个id，用于批量导入等场景
+func GetIDs(n int) ([]uint64, error) {
+	if err := check(); err != nil {
+		return nil, err
+	}
+	return node.NextIDs(n)
 }
 
-//func main()  {
-//	if err := Init(1);err!=nil{
-//		fmt.Printf("Init failed,err:%v\n",err)
-//		return
-//	}
-//	id,_ := GetID()
-//	fmt.Println(id)
-//}
+func check() error {
+	if node == nil {
+		return errors.New("snoy flake not inited")
+	}
+	if leaseLost() {
+		return ErrLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// stats ID生成的计数器，通过 Stats 对外暴露
+var stats struct {
+	generated         atomic.Uint64 // 生成的ID总数
+	batches           atomic.Uint64 // GetIDs 调用次数
+	sequenceOverflows atomic.Uint64 // 同一个10ms内序列号用完的次数
+	rollbacks         atomic.Uint64 // 检测到时钟回拨的次数
+	rollbackFailures  atomic.Uint64 // 因时钟回拨返回错误的次数
+	rollbackWaitNanos atomic.Uint64 // 因时钟回拨累计等待的时间
+	poolHits          atomic.Uint64 // 从缓冲池直接拿到ID的次数
+	poolMisses        atomic.Uint64 // 缓冲池为空，现场生成的次数
+}
+
+// Metrics ID生成的统计数据
+type Metrics struct {
+	Generated         uint64        `json:"generated"`
+	Batches           uint64        `json:"batches"`
+	SequenceOverflows uint64        `json:"sequence_overflows"`
+	Rollbacks         uint64        `json:"rollbacks"`
+	RollbackFailures  uint64        `json:"rollback_failures"`
+	RollbackWait      time.Duration `json:"rollback_wait_ns"`
+	PoolHits          uint64        `json:"pool_hits"`
+	PoolMisses        uint64        `json:"pool_misses"`
+	PoolSize          int           `json:"pool_size"` // 缓冲池中剩余的ID数
+}
+
+// Stats 返回当前的统计数据
+func Stats() Metrics {
+	m := Metrics{
+		Generated:         stats.generated.Load(),
+		Batches:           stats.batches.Load(),
+		SequenceOverflows: stats.sequenceOverflows.Load(),
+		Rollbacks:         stats.rollbacks.Load(),
+		RollbackFailures:  stats.rollbackFailures.Load(),
+		RollbackWait:      time.Duration(stats.rollbackWaitNanos.Load()),
+		PoolHits:          stats.poolHits.Load(),
+		PoolMisses:        stats.poolMisses.Load(),
+	}
+	if idPool != nil {
+		m.PoolSize = len(idPool.ids)
+	}
+	return m
+}
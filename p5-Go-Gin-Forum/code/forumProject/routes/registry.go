@@ -0,0 +1,10 @@
+package routes
+
+import "github.com/gin-gonic/gin"
+
+// registrars gen crud 生成的路由文件（*_gen.go）在init中注册，Setup时挂到engine上
+var registrars []func(r *gin.Engine)
+
+func register(fn func(r *gin.Engine)) {
+	registrars = append(registrars, fn)
+}
@@ -12,12 +12,10 @@ package routes
 import (
 	"forumProject/controller"
 	"forumProject/logger"
-	snowflake "forumProject/pkg/sonwflake"
+	"forumProject/middlewares"
+	"forumProject/pkg/tracing"
 	"forumProject/settings"
 	"net/http"
-	"strconv"
-
-	"go.uber.org/zap"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,22 +25,41 @@ func Setup(mode string) *gin.Engine {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.New()
-	r.Use(logger.GinLogger(), logger.GinRecovery(true))
+	// tracing要在GinLogger之前，访问日志才能带上trace_id
+	r.Use(tracing.GinMiddleware(settings.Get().Name), logger.GinLogger(), logger.GinRecovery(true))
 
 	r.GET("/version", func(c *gin.Context) {
-		c.String(http.StatusOK, settings.Conf.Version)
+		c.String(http.StatusOK, settings.Get().Version)
 	})
 
-	r.GET("/sf", func(c *gin.Context) {
-		ID, err := snowflake.GetID()
-		if err != nil {
-			zap.L().Fatal("sf getID failed")
-		}
-		c.String(http.StatusOK, strconv.FormatUint(ID, 10))
-	})
+	r.GET("/sf", controller.SnowflakeHandler)
+	r.GET("/sf/stats", middlewares.AdminAuth(), controller.SnowflakeStatsHandler)
+	r.GET("/sf/:id/decode", middlewares.AdminAuth(), controller.SnowflakeDecodeHandler)
 
 	r.POST("/signup", controller.SignUpHandler)
 	r.POST("/login", controller.LoginHandler)
 
+	r.GET("/post/:id", controller.PostDetailHandler)
+
+	admin := r.Group("/admin", middlewares.AdminAuth())
+	{
+		admin.GET("/tasks", controller.TaskListHandler)
+		admin.GET("/tasks/:name/history", controller.TaskHistoryHandler)
+		admin.POST("/tasks/:name/run", controller.TaskRunHandler)
+
+		admin.GET("/log/level", controller.LogLevelHandler)
+		admin.PUT("/log/level", controller.SetLogLevelHandler)
+		admin.DELETE("/log/level", controller.ResetLogLevelHandler)
+		admin.GET("/log/capture", controller.LogCaptureHandler)
+		admin.PUT("/log/capture", controller.SetLogCaptureHandler)
+
+		admin.GET("/audit", controller.AuditHandler)
+	}
+
+	// 生成的增删改查路由
+	for _, fn := range registrars {
+		fn(r)
+	}
+
 	return r
 }
@@ -0,0 +1,13 @@
+package settings
+
+import (
+	kitsettings "kit/settings"
+)
+
+// EnvPrefix 环境变量前缀，例如 mysql.password 对应 FORUM_MYSQL_PASSWORD
+const EnvPrefix = "FORUM"
+
+// Redacted 返回用于打印的配置，带 secret:"true" 标签的字段会被打码
+func (c *AppConfig) Redacted() map[string]interface{} {
+	return kitsettings.Redacted(c)
+}
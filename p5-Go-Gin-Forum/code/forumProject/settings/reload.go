@@ -0,0 +1,140 @@
+package settings
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// current 保存当前生效的配置快照（*AppConfig）
+var current atomic.Pointer[AppConfig]
+
+var (
+	mu          sync.Mutex // 串行化热加载与订阅
+	subscribers []subscriber
+)
+
+type subscriber struct {
+	name    string
+	fn      func(old, new *AppConfig) error
+	restore func(old, next *AppConfig)
+}
+
+// Get 返回当前生效的配置快照
+func Get() *AppConfig {
+	return current.Load()
+}
+
+// Subscribe 注册配置变更回调，热加载时在替换快照之前按注册顺序调用
+// 回调失败时调用 restore 把它负责的配置段还原为旧值，快照中不会出现没有生效的配置
+func Subscribe(name string, fn func(old, new *AppConfig) error, restore func(old, next *AppConfig)) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, subscriber{name: name, fn: fn, restore: restore})
+}
+
+// reload 重新解析配置文件：校验 -> 剔除需要重启的修改 -> 通知订阅者生效 -> 原子替换
+func reload() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	zap.L().Info("配置文件修改了，开始热加载...")
+	// secrets文件可能也被轮换了，Reload 会重新读一遍
+	next, err := loader.Reload()
+	if err != nil {
+		zap.L().Error("reload config: invalid config, keep old config", zap.Error(err))
+		return
+	}
+
+	old := Get()
+	if rejected := keepRestartOnly(old, next); len(rejected) > 0 {
+		zap.L().Warn("reload config: these changes need a restart and are rejected",
+			zap.Strings("fields", rejected))
+	}
+
+	for _, s := range subscribers {
+		if err := s.fn(old, next); err != nil {
+			// 这一段没有生效，快照中保持旧值，下次修改配置文件时会再试
+			s.restore(old, next)
+			zap.L().Error("reload config: subscriber failed, its changes are rejected",
+				zap.String("subscriber", s.name), zap.Error(err))
+		}
+	}
+	current.Store(next)
+	zap.L().Info("配置热加载完成")
+}
+
+// keepRestartOnly 把只有重启才能生效的字段还原为旧值，返回被拒绝的字段名
+// 这样快照始终和正在运行的状态一致
+func keepRestartOnly(old, next *AppConfig) (rejected []string) {
+	check := func(name string, changed bool, restore func()) {
+		if changed {
+			rejected = append(rejected, name)
+			restore()
+		}
+	}
+
+	check("name", old.Name != next.Name, func() { next.Name = old.Name })
+	check("mode", old.Mode != next.Mode, func() { next.Mode = old.Mode })
+	check("port", old.Port != next.Port, func() { next.Port = old.Port })
+	check("start_time", old.StartTime != next.StartTime, func() { next.StartTime = old.StartTime })
+	check("machine_id", old.MachineID != next.MachineID, func() { next.MachineID = old.MachineID })
+	// 修改盐会让已有用户全部无法登录
+	check("salt", old.Salt != next.Salt, func() { next.Salt = old.Salt })
+
+	ol, nl := old.LogConfig, next.LogConfig
+	check("log.filename", ol.Filename != nl.Filename, func() { nl.Filename = ol.Filename })
+	check("log.max_size", ol.MaxSize != nl.MaxSize, func() { nl.MaxSize = ol.MaxSize })
+	check("log.max_age", ol.MaxAge != nl.MaxAge, func() { nl.MaxAge = ol.MaxAge })
+	check("log.max_backups", ol.MaxBackups != nl.MaxBackups, func() { nl.MaxBackups = ol.MaxBackups })
+
+	om, nm := old.MySQLConfig, next.MySQLConfig
+	check("mysql.host", om.Host != nm.Host, func() { nm.Host = om.Host })
+	check("mysql.port", om.Port != nm.Port, func() { nm.Port = om.Port })
+	check("mysql.user", om.User != nm.User, func() { nm.User = om.User })
+	check("mysql.password", om.Password != nm.Password, func() { nm.Password = om.Password })
+	check("mysql.dbname", om.DbName != nm.DbName, func() { nm.DbName = om.DbName })
+	check("mysql.replicas", !slices.Equal(om.Replicas, nm.Replicas), func() { nm.Replicas = om.Replicas })
+
+	// 机器ID在启动时确定，整段保持旧值
+	if (old.LeaseConfig == nil) != (next.LeaseConfig == nil) ||
+		(old.LeaseConfig != nil && *old.LeaseConfig != *next.LeaseConfig) {
+		rejected = append(rejected, "machine_id_lease")
+		next.LeaseConfig = old.LeaseConfig
+	}
+
+	if (old.SnowflakeConfig == nil) != (next.SnowflakeConfig == nil) ||
+		(old.SnowflakeConfig != nil && *old.SnowflakeConfig != *next.SnowflakeConfig) {
+		rejected = append(rejected, "snowflake")
+		next.SnowflakeConfig = old.SnowflakeConfig
+	}
+
+	// worker 在启动时创建，整段保持旧值
+	if (old.JobsConfig == nil) != (next.JobsConfig == nil) ||
+		(old.JobsConfig != nil && *old.JobsConfig != *next.JobsConfig) {
+		rejected = append(rejected, "jobs")
+		next.JobsConfig = old.JobsConfig
+	}
+
+	if (old.AuditConfig == nil) != (next.AuditConfig == nil) ||
+		(old.AuditConfig != nil && *old.AuditConfig != *next.AuditConfig) {
+		rejected = append(rejected, "audit")
+		next.AuditConfig = old.AuditConfig
+	}
+
+	if (old.SchedulerConfig == nil) != (next.SchedulerConfig == nil) ||
+		(old.SchedulerConfig != nil && *old.SchedulerConfig != *next.SchedulerConfig) {
+		rejected = append(rejected, "scheduler")
+		next.SchedulerConfig = old.SchedulerConfig
+	}
+
+	// tracer provider 在启动时创建，整段保持旧值
+	if (old.TraceConfig == nil) != (next.TraceConfig == nil) ||
+		(old.TraceConfig != nil && *old.TraceConfig != *next.TraceConfig) {
+		rejected = append(rejected, "trace")
+		next.TraceConfig = old.TraceConfig
+	}
+	return
+}
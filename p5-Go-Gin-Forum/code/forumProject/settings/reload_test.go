@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloadRestoresFailedSection(t *testing.T) {
+	src, err := os.ReadFile("../config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err = os.WriteFile(file, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = Init(file); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	old := subscribers
+	defer func() { subscribers = old }()
+
+	// 新配置在订阅者生效之前不能被读到
+	var seen *AppConfig
+	Subscribe("redis", func(old, new *AppConfig) error {
+		seen = Get()
+		return errors.New("dial failed")
+	}, func(old, next *AppConfig) {
+		next.RedisConfig = old.RedisConfig
+	})
+	Subscribe("mysql", func(old, new *AppConfig) error { return nil }, func(old, next *AppConfig) {
+		next.MySQLConfig = old.MySQLConfig
+	})
+
+	before := Get()
+	changed := strings.Replace(string(src), "pool_size: 100", "pool_size: 7", 1)
+	changed = strings.Replace(changed, "max_idle_conns: 50", "max_idle_conns: 9", 1)
+	if changed == string(src) {
+		t.Fatal("config.yaml changed, update the test")
+	}
+	if err = os.WriteFile(file, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 等监听到文件修改、热加载完成
+	deadline := time.Now().Add(5 * time.Second)
+	for Get() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("config was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if seen != before {
+		t.Error("snapshot was published before subscribers ran")
+	}
+	after := Get()
+	if after.RedisConfig.PoolSize != before.RedisConfig.PoolSize {
+		t.Errorf("redis.pool_size = %d, failed section should keep %d", after.RedisConfig.PoolSize, before.RedisConfig.PoolSize)
+	}
+	if after.MySQLConfig.MaxIdleConns != 9 {
+		t.Errorf("mysql.max_idle_conns = %d, want 9", after.MySQLConfig.MaxIdleConns)
+	}
+}
@@ -10,27 +10,31 @@
 package settings
 
 import (
-	"fmt"
-
-	"github.com/fsnotify/fsnotify"
-	"github.com/spf13/viper"
+	kitsettings "kit/settings"
 )
 
-// Conf 全局变量，用来保存程序的所有配置信息
-var Conf = new(AppConfig)
-
+// AppConfig 程序的所有配置信息
+// 通过 Get 拿到的是只读快照，热加载时会整体替换而不是原地修改，使用方不要修改其中的字段
 type AppConfig struct {
-	Name         string `mapstructure:"name"`
-	Mode         string `mapstructure:"mode"`
-	Version      string `mapstructure:"version"`
-	Port         int    `mapstructure:"port"`
-	StartTime    string `mapstructure:"start_time"`
-	MachineID    uint16 `mapstructure:"machine_id"`
-	WaitTime     int    `mapstructure:"wait_time"`
-	Salt         string `mapstructure:"salt"`
-	*LogConfig   `mapstructure:"log"`
-	*MySQLConfig `mapstructure:"mysql"`
-	*RedisConfig `mapstructure:"redis"`
+	Name                  string `mapstructure:"name"`
+	Mode                  string `mapstructure:"mode"`
+	Version               string `mapstructure:"version"`
+	Port                  int    `mapstructure:"port"`
+	StartTime             string `mapstructure:"start_time"`
+	MachineID             uint16 `mapstructure:"machine_id"`
+	WaitTime              int    `mapstructure:"wait_time"`
+	Salt                  string `mapstructure:"salt" secret:"true"`
+	AdminToken            string `mapstructure:"admin_token" secret:"true"` // 为空时关闭管理接口
+	*LogConfig            `mapstructure:"log"`
+	*MySQLConfig          `mapstructure:"mysql"`
+	*RedisConfig          `mapstructure:"redis"`
+	*TraceConfig          `mapstructure:"trace"`
+	*LeaseConfig          `mapstructure:"machine_id_lease"`
+	*SnowflakeConfig      `mapstructure:"snowflake"`
+	*JobsConfig           `mapstructure:"jobs"`
+	*SchedulerConfig      `mapstructure:"scheduler"`
+	*AuditConfig          `mapstructure:"audit"`
+	*PasswordPolicyConfig `mapstructure:"password_policy"`
 }
 
 type LogConfig struct {
@@ -39,61 +43,161 @@ type LogConfig struct {
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxAge     int    `mapstructure:"max_age"`
 	MaxBackups int    `mapstructure:"max_backups"`
+	// Packages 按包单独设置级别，key是相对模块根目录的包路径，如 dao: debug 对 dao 下所有包生效
+	Packages           map[string]string `mapstructure:"packages"`
+	*LogSamplingConfig `mapstructure:"sampling"`
+	// 写日志前打码的请求头和JSON/表单字段（不区分大小写），在内置的 Authorization、Cookie、password 等基础上追加
+	RedactHeaders     []string `mapstructure:"redact_headers"`
+	RedactFields      []string `mapstructure:"redact_fields"`
+	*LogCaptureConfig `mapstructure:"capture"`
+}
+
+// LogCaptureConfig 在访问日志中记录请求和响应体，用于排查问题
+// 指定的路由总是记录，其他请求按百分比抽样；只记录文本类型，并按打码规则处理
+type LogCaptureConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Routes        []string `mapstructure:"routes"`         // 路由模板，如 /post/:id 或 POST /signup
+	SamplePercent float64  `mapstructure:"sample_percent"` // 0到100
+	MaxBody       int      `mapstructure:"max_body"`       // 每个请求体、响应体最多记录的字节数
+}
+
+// LogSamplingConfig 访问日志采样：同一路径每秒前 initial 条全部记录，之后每 thereafter 条记录一条
+// 只对成功的请求采样，4xx、5xx 的请求总是记录
+type LogSamplingConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	Initial    int  `mapstructure:"initial"`
+	Thereafter int  `mapstructure:"thereafter"`
 }
 
 type MySQLConfig struct {
 	Host         string `mapstructure:"host"`
 	User         string `mapstructure:"user"`
-	Password     string `mapstructure:"password"`
+	Password     string `mapstructure:"password" secret:"true"`
 	DbName       string `mapstructure:"dbname"`
 	Port         int    `mapstructure:"port"`
 	MaxOpenConns int    `mapstructure:"max_open_conns"`
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+	// 只读从库的DSN，如 user:password@tcp(10.0.0.2:3306)/bluebell，为空时读写都走主库
+	// 环境变量中多个DSN用逗号分隔
+	Replicas []string `mapstructure:"replicas" secret:"true"`
 }
 
 type RedisConfig struct {
-	Host     string `mapstructure:"host"`
-	Password string `mapstructure:"password"`
-	Port     int    `mapstructure:"port"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"pool_size"`
+	Mode       string   `mapstructure:"mode"`        // single（默认）、sentinel、cluster
+	Host       string   `mapstructure:"host"`        // single模式的地址
+	Port       int      `mapstructure:"port"`        // single模式的端口
+	Addrs      []string `mapstructure:"addrs"`       // sentinel模式为哨兵地址，cluster模式为种子节点，host:port
+	MasterName string   `mapstructure:"master_name"` // sentinel模式的主节点名
+	Password   string   `mapstructure:"password" secret:"true"`
+	DB         int      `mapstructure:"db"` // cluster模式只能是0
+	PoolSize   int      `mapstructure:"pool_size"`
+	// 超时时间（毫秒），0表示使用go-redis的默认值
+	DialTimeout  int `mapstructure:"dial_timeout"`
+	ReadTimeout  int `mapstructure:"read_timeout"`
+	WriteTimeout int `mapstructure:"write_timeout"`
+	PoolTimeout  int `mapstructure:"pool_timeout"`
+	// 单条命令的超时时间（毫秒），和请求本身的deadline取较早的一个，0表示不限制
+	// op_timeouts 按命令名（小写）单独设置，如 {"eval": 500}，pipeline 使用 "pipeline"
+	OpTimeout       int            `mapstructure:"op_timeout"`
+	OpTimeouts      map[string]int `mapstructure:"op_timeouts"`
+	*RedisTLSConfig `mapstructure:"tls"`
 }
 
-func Init(configFileName string) (err error) {
+// RedisTLSConfig 连接redis时使用TLS，云厂商的redis通常需要开启
+type RedisTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`   // 为空时使用系统根证书
+	CertFile           string `mapstructure:"cert_file"` // 双向认证时的客户端证书
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+type TraceConfig struct {
+	Exporter    string  `mapstructure:"exporter"` // otlp、stdout、file，为空或none时不导出
+	Endpoint    string  `mapstructure:"endpoint"` // otlp collector地址，如 127.0.0.1:4318
+	Insecure    bool    `mapstructure:"insecure"` // otlp是否使用http明文传输
+	Filename    string  `mapstructure:"filename"` // exporter为file时的输出文件
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
 
-	// 1.相对路径（是相对于执行的位置）
-	viper.SetConfigFile(configFileName)
+// LeaseConfig 多实例部署时从Redis租用雪花算法的机器ID，未开启时使用 machine_id
+type LeaseConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	TTL          int    `mapstructure:"ttl"` // 租约有效期（秒），每 ttl/3 续约一次
+	MaxMachineID uint16 `mapstructure:"max_machine_id"`
+}
 
-	// 2.注意不要重名
-	//viper.SetConfigName("config")
-	//viper.AddConfigPath(".")
-	//viper.AddConfigPath("./conf")
+// SnowflakeConfig 雪花算法的生成策略
+type SnowflakeConfig struct {
+	Rollback        string `mapstructure:"rollback"`          // 时钟回拨时 wait（等待时钟追上）或 fail（直接报错）
+	MaxRollbackWait int    `mapstructure:"max_rollback_wait"` // wait策略最多等待的毫秒数
+	BufferSize      int    `mapstructure:"buffer_size"`       // 预生成ID的缓冲大小，0为关闭
+}
 
-	// 3.远程配置中心获取 使用什么格式去解析
-	//viper.SetConfigType("yaml") // json
+// JobsConfig 基于redis的后台任务队列
+type JobsConfig struct {
+	Workers     int `mapstructure:"workers"`      // 并发执行任务的goroutine数，0为不启动worker（只投递）
+	MaxAttempts int `mapstructure:"max_attempts"` // 最多执行次数，用完后进入死信队列
+	Backoff     int `mapstructure:"backoff"`      // 第一次重试的等待时间（毫秒），之后每次翻倍
+	MaxBackoff  int `mapstructure:"max_backoff"`  // 重试等待时间的上限（毫秒）
+	Timeout     int `mapstructure:"timeout"`      // 单个任务的执行超时（秒）
+	// 任务被取走后超过这个时间（秒）没有确认，认为worker已经挂了，由其他worker重新执行
+	VisibilityTimeout int `mapstructure:"visibility_timeout"`
+}
 
-	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			fmt.Println("Not Found config! ")
-		} else {
-			fmt.Printf("Fatal error config file: %s \n", err)
-		}
-	}
+// AuditConfig 审计日志，写到单独的文件中，只追加不修改
+type AuditConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Filename   string `mapstructure:"filename"`
+	MaxSize    int    `mapstructure:"max_size"`
+	MaxAge     int    `mapstructure:"max_age"`
+	MaxBackups int    `mapstructure:"max_backups"`
+}
+
+// PasswordPolicyConfig 注册时的密码强度要求，由 password 校验规则读取，修改后热加载生效
+type PasswordPolicyConfig struct {
+	MinLength  int `mapstructure:"min_length"`
+	MaxLength  int `mapstructure:"max_length"`  // 0表示不限制
+	MinClasses int `mapstructure:"min_classes"` // 大写字母、小写字母、数字、符号中至少包含几类，0到4
+}
+
+// SchedulerConfig 定时任务，多个实例通过redis选出一个leader执行
+type SchedulerConfig struct {
+	Enabled     bool `mapstructure:"enabled"`
+	LockTTL     int  `mapstructure:"lock_ttl"`     // leader锁的有效期（秒），每 lock_ttl/3 续约一次
+	HistorySize int  `mapstructure:"history_size"` // 每个任务保留的执行记录条数
+}
 
-	// 把读取到的配置信息反序列化到 Conf 变量中
-	if err := viper.Unmarshal(Conf); err != nil {
-		fmt.Printf("viper.Unmarshal failed, err:%v\n", err)
+// loader Init 时创建，热加载时用它重新解析
+var loader *kitsettings.Loader[AppConfig]
+
+func Init(configFileName string) (err error) {
+	l := newLoader(configFileName)
+	conf, err := l.Load()
+	if err != nil {
+		return err
 	}
+	loader = l
+	current.Store(conf)
 
-	viper.WatchConfig()
-	// 小回调的钩子
-	viper.OnConfigChange(func(in fsnotify.Event) {
-		fmt.Println("配置文件修改了，重新加载到全局Conf ...")
-		if err := viper.Unmarshal(Conf); err != nil {
-			fmt.Printf("viper.Unmarshal failed, err:%v\n", err)
-		}
-	})
+	// 小回调的钩子：校验通过后才替换快照并通知订阅者
+	loader.Watch(reload)
 
 	return nil
 }
+
+// Check 只加载并校验配置文件，不启动监听，供 `forumProject config check` 在CI中使用
+func Check(configFileName string) error {
+	_, err := newLoader(configFileName).Load()
+	return err
+}
+
+// newLoader 配置文件之上叠加环境变量 FORUM_MYSQL_PASSWORD、FORUM_REDIS_PORT ...
+// 和secrets文件 FORUM_MYSQL_PASSWORD_FILE ...，加载后调用 Validate 做完整校验
+func newLoader(configFileName string) *kitsettings.Loader[AppConfig] {
+	return kitsettings.NewLoader[AppConfig](kitsettings.Options{
+		File:      configFileName,
+		EnvPrefix: EnvPrefix,
+	})
+}
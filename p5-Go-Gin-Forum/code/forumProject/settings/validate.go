@@ -0,0 +1,258 @@
+package settings
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap/zapcore"
+)
+
+// StartTimeLayout start_time 的日期格式
+const StartTimeLayout = "2006-01-02"
+
+// ValidationError 配置校验失败时返回，包含所有不合法的配置项，而不是遇到第一个就返回
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e, "\n  - ")
+}
+
+type checker struct {
+	problems ValidationError
+}
+
+func (c *checker) add(key, format string, args ...interface{}) {
+	c.problems = append(c.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *checker) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		c.add(key, "is required")
+	}
+}
+
+func (c *checker) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		c.add(key, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+func (c *checker) nonNegative(key string, n int) {
+	if n < 0 {
+		c.add(key, "must not be negative, got %d", n)
+	}
+}
+
+func (c *checker) positive(key string, n int) {
+	if n <= 0 {
+		c.add(key, "must be greater than 0, got %d", n)
+	}
+}
+
+// Validate 校验整份配置，启动和热加载都会调用
+func (conf *AppConfig) Validate() error {
+	c := new(checker)
+
+	c.required("name", conf.Name)
+	switch conf.Mode {
+	case "dev", "release", "test":
+	default:
+		c.add("mode", "must be one of dev, release, test, got %q", conf.Mode)
+	}
+	c.port("port", conf.Port)
+	if t, err := time.Parse(StartTimeLayout, conf.StartTime); err != nil {
+		c.add("start_time", "must be a date like %s, got %q", StartTimeLayout, conf.StartTime)
+	} else if t.After(time.Now()) {
+		// 雪花算法的起始时间不能晚于当前时间
+		c.add("start_time", "must not be in the future, got %q", conf.StartTime)
+	}
+	// 为0时关闭服务的context一创建就超时，正在处理的请求会被直接断开
+	c.positive("wait_time", conf.WaitTime)
+	c.required("salt", conf.Salt)
+
+	// 三个必需的配置段缺失时，嵌入的指针为nil，后续的Init会直接panic
+	if conf.LogConfig == nil {
+		c.add("log", "section is required")
+	} else {
+		if _, err := zapcore.ParseLevel(conf.LogConfig.Level); err != nil {
+			c.add("log.level", "must be one of debug, info, warn, error, dpanic, panic, fatal, got %q", conf.LogConfig.Level)
+		}
+		c.required("log.filename", conf.LogConfig.Filename)
+		c.nonNegative("log.max_size", conf.LogConfig.MaxSize)
+		c.nonNegative("log.max_age", conf.LogConfig.MaxAge)
+		c.nonNegative("log.max_backups", conf.LogConfig.MaxBackups)
+		for pkg, level := range conf.LogConfig.Packages {
+			if _, err := zapcore.ParseLevel(level); err != nil {
+				c.add("log.packages."+pkg, "must be one of debug, info, warn, error, dpanic, panic, fatal, got %q", level)
+			}
+		}
+		if cc := conf.LogConfig.LogCaptureConfig; cc != nil && cc.Enabled {
+			if cc.SamplePercent < 0 || cc.SamplePercent > 100 {
+				c.add("log.capture.sample_percent", "must be between 0 and 100, got %v", cc.SamplePercent)
+			}
+			if cc.MaxBody < 1 {
+				c.add("log.capture.max_body", "must be at least 1, got %d", cc.MaxBody)
+			}
+		}
+		if sc := conf.LogConfig.LogSamplingConfig; sc != nil && sc.Enabled {
+			if sc.Initial < 1 {
+				c.add("log.sampling.initial", "must be at least 1, got %d", sc.Initial)
+			}
+			if sc.Thereafter < 1 {
+				c.add("log.sampling.thereafter", "must be at least 1, got %d", sc.Thereafter)
+			}
+		}
+	}
+
+	if conf.MySQLConfig == nil {
+		c.add("mysql", "section is required")
+	} else {
+		c.required("mysql.host", conf.MySQLConfig.Host)
+		c.port("mysql.port", conf.MySQLConfig.Port)
+		c.required("mysql.user", conf.MySQLConfig.User)
+		c.required("mysql.dbname", conf.MySQLConfig.DbName)
+		c.nonNegative("mysql.max_open_conns", conf.MySQLConfig.MaxOpenConns)
+		c.nonNegative("mysql.max_idle_conns", conf.MySQLConfig.MaxIdleConns)
+		for i, dsn := range conf.MySQLConfig.Replicas {
+			// 错误信息里可能带密码，不打印DSN本身
+			if _, err := mysql.ParseDSN(dsn); err != nil {
+				c.add(fmt.Sprintf("mysql.replicas[%d]", i), "invalid dsn: %v", err)
+			}
+		}
+	}
+
+	if conf.RedisConfig == nil {
+		c.add("redis", "section is required")
+	} else {
+		rc := conf.RedisConfig
+		switch rc.Mode {
+		case "", "single":
+			c.required("redis.host", rc.Host)
+			c.port("redis.port", rc.Port)
+		case "sentinel":
+			c.required("redis.master_name", rc.MasterName)
+			if len(rc.Addrs) == 0 {
+				c.add("redis.addrs", "is required in sentinel mode")
+			}
+		case "cluster":
+			if len(rc.Addrs) == 0 {
+				c.add("redis.addrs", "is required in cluster mode")
+			}
+			if rc.DB != 0 {
+				c.add("redis.db", "must be 0 in cluster mode, got %d", rc.DB)
+			}
+		default:
+			c.add("redis.mode", "must be one of single, sentinel, cluster, got %q", rc.Mode)
+		}
+		for i, addr := range rc.Addrs {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				c.add(fmt.Sprintf("redis.addrs[%d]", i), "must be host:port, got %q", addr)
+			}
+		}
+		if rc.DB < 0 || rc.DB > 15 {
+			c.add("redis.db", "must be between 0 and 15, got %d", rc.DB)
+		}
+		c.nonNegative("redis.pool_size", rc.PoolSize)
+		c.nonNegative("redis.dial_timeout", rc.DialTimeout)
+		c.nonNegative("redis.read_timeout", rc.ReadTimeout)
+		c.nonNegative("redis.write_timeout", rc.WriteTimeout)
+		c.nonNegative("redis.pool_timeout", rc.PoolTimeout)
+		c.nonNegative("redis.op_timeout", rc.OpTimeout)
+		for name, ms := range rc.OpTimeouts {
+			c.nonNegative("redis.op_timeouts."+name, ms)
+		}
+		if t := rc.RedisTLSConfig; t != nil && t.Enabled && (t.CertFile == "") != (t.KeyFile == "") {
+			c.add("redis.tls", "cert_file and key_file must be set together")
+		}
+	}
+
+	// trace 段可选
+	if t := conf.TraceConfig; t != nil {
+		switch t.Exporter {
+		case "", "none", "stdout":
+		case "otlp":
+			c.required("trace.endpoint", t.Endpoint)
+		case "file":
+			c.required("trace.filename", t.Filename)
+		default:
+			c.add("trace.exporter", "must be one of otlp, stdout, file, none, got %q", t.Exporter)
+		}
+		if t.SampleRatio < 0 || t.SampleRatio > 1 {
+			c.add("trace.sample_ratio", "must be between 0 and 1, got %v", t.SampleRatio)
+		}
+	}
+
+	// machine_id_lease 段可选
+	if l := conf.LeaseConfig; l != nil && l.Enabled {
+		if l.TTL < 3 {
+			c.add("machine_id_lease.ttl", "must be at least 3 seconds, got %d", l.TTL)
+		}
+		if l.MaxMachineID == 0 {
+			c.add("machine_id_lease.max_machine_id", "must be greater than 0")
+		}
+	}
+
+	// snowflake 段可选
+	if sc := conf.SnowflakeConfig; sc != nil {
+		switch sc.Rollback {
+		case "", "wait", "fail":
+		default:
+			c.add("snowflake.rollback", "must be wait or fail, got %q", sc.Rollback)
+		}
+		c.nonNegative("snowflake.max_rollback_wait", sc.MaxRollbackWait)
+		c.nonNegative("snowflake.buffer_size", sc.BufferSize)
+	}
+
+	// jobs 段可选
+	if j := conf.JobsConfig; j != nil {
+		c.nonNegative("jobs.workers", j.Workers)
+		c.nonNegative("jobs.max_attempts", j.MaxAttempts)
+		c.nonNegative("jobs.backoff", j.Backoff)
+		c.nonNegative("jobs.max_backoff", j.MaxBackoff)
+		c.nonNegative("jobs.timeout", j.Timeout)
+		c.nonNegative("jobs.visibility_timeout", j.VisibilityTimeout)
+		if j.VisibilityTimeout > 0 && j.Timeout >= j.VisibilityTimeout {
+			// 否则还在执行的任务会被别的worker认领，重复执行
+			c.add("jobs.visibility_timeout", "must be greater than jobs.timeout")
+		}
+	}
+
+	// audit 段可选
+	if ac := conf.AuditConfig; ac != nil && ac.Enabled {
+		c.required("audit.filename", ac.Filename)
+		if conf.LogConfig != nil && ac.Filename == conf.LogConfig.Filename {
+			c.add("audit.filename", "must differ from log.filename")
+		}
+		c.nonNegative("audit.max_size", ac.MaxSize)
+		c.nonNegative("audit.max_age", ac.MaxAge)
+		c.nonNegative("audit.max_backups", ac.MaxBackups)
+	}
+
+	// scheduler 段可选
+	if sc := conf.SchedulerConfig; sc != nil && sc.Enabled {
+		if sc.LockTTL < 3 {
+			c.add("scheduler.lock_ttl", "must be at least 3 seconds, got %d", sc.LockTTL)
+		}
+		c.nonNegative("scheduler.history_size", sc.HistorySize)
+	}
+
+	// password_policy 段可选
+	if pc := conf.PasswordPolicyConfig; pc != nil {
+		c.nonNegative("password_policy.min_length", pc.MinLength)
+		c.nonNegative("password_policy.max_length", pc.MaxLength)
+		if pc.MaxLength > 0 && pc.MaxLength < pc.MinLength {
+			c.add("password_policy.max_length", "must not be less than min_length, got %d", pc.MaxLength)
+		}
+		if pc.MinClasses < 0 || pc.MinClasses > 4 {
+			c.add("password_policy.min_classes", "must be between 0 and 4, got %d", pc.MinClasses)
+		}
+	}
+
+	if len(c.problems) > 0 {
+		return c.problems
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package settings
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateWaitTime(t *testing.T) {
+	if err := Init("../config.yaml"); err != nil {
+		t.Fatalf("config.yaml should be valid: %v", err)
+	}
+	for _, wait := range []int{0, -1} {
+		conf := *Get()
+		conf.WaitTime = wait
+		var verr ValidationError
+		if err := conf.Validate(); !errors.As(err, &verr) || !strings.Contains(err.Error(), "wait_time: must be greater than 0") {
+			t.Errorf("wait_time=%d: err = %v, want wait_time rejected", wait, err)
+		}
+	}
+}
@@ -0,0 +1,691 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stubMarker 只生成一次的文件中的标记，有这个标记才认为是之前生成的
+const stubMarker = "// 由 gen crud 生成，可以修改，重新生成时不会覆盖"
+
+// crudOptions 生成参数
+type crudOptions struct {
+	SQL     string
+	Tables  []string // 为空时生成文件中的所有表
+	Project string
+	Name    string // 覆盖Go中的名字，只能和一张表一起使用
+	Auth    string // admin 或 none
+}
+
+// resource 一张表对应的生成数据
+type resource struct {
+	Module       string
+	Source       string // SQL文件名
+	Table        string
+	TableComment string
+	Name         string // Go中的名字，如 BlogPost
+	Plural       string // BlogPosts
+	Unexported   string // blogPost
+	Route        string // blog_post
+
+	Key          *field
+	KeyAuto      bool // 主键自增，插入后从 LastInsertId 取
+	KeySnowflake bool // 主键由雪花算法生成
+	Fields       []*field
+	Params       []*field
+	Defaults     []*field
+
+	ColumnsSQL string
+	InsertSQL  string
+	InsertArgs string
+	GetSQL     string
+	ListSQL    string
+	UpdateSQL  string
+	UpdateArgs string
+	DeleteSQL  string
+
+	Snowflake string // 雪花算法包的导入路径
+	LogExpr   string // 记录日志的表达式
+	Logger    bool
+	Auth      bool
+}
+
+type field struct {
+	Column    string
+	Name      string
+	GoType    string // 模型中的类型，可以为NULL的列是指针
+	ParamType string // 参数中的类型，可选的参数是指针
+	Binding   string
+	Comment   string
+	Default   string // Go字面量，NewXxx 中使用
+	Pointer   bool
+	Optional  bool
+}
+
+func (f *field) ParamPointer() bool { return strings.HasPrefix(f.ParamType, "*") }
+
+// ModelTime 模型是否用到 time 包
+func (r *resource) ModelTime() bool {
+	for _, f := range r.Fields {
+		if strings.Contains(f.GoType, "time.") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKey 把路径参数转成主键类型的表达式，结果在 id 和 err 中
+func (r *resource) ParseKey() string {
+	switch t := r.Key.GoType; t {
+	case "string":
+		return `id, err := c.Param("id"), error(nil)`
+	case "uint64", "uint32", "uint16", "uint8":
+		bits := strings.TrimPrefix(t, "uint")
+		if t == "uint64" {
+			return `id, err := strconv.ParseUint(c.Param("id"), 10, 64)`
+		}
+		return fmt.Sprintf(`u, err := strconv.ParseUint(c.Param("id"), 10, %s)
+	id := %s(u)`, bits, t)
+	default:
+		bits := strings.TrimPrefix(t, "int")
+		if t == "int64" {
+			return `id, err := strconv.ParseInt(c.Param("id"), 10, 64)`
+		}
+		return fmt.Sprintf(`n, err := strconv.ParseInt(c.Param("id"), 10, %s)
+	id := %s(n)`, bits, t)
+	}
+}
+
+// KeyZap 记录主键的zap字段
+func (r *resource) KeyZap() string {
+	switch t := r.Key.GoType; {
+	case t == "string":
+		return `zap.String("id", id)`
+	case strings.HasPrefix(t, "uint"):
+		return `zap.Uint64("id", uint64(id))`
+	default:
+		return `zap.Int64("id", int64(id))`
+	}
+}
+
+func crudCommand(args []string) int {
+	var (
+		opts   crudOptions
+		tables string
+	)
+	flags := flag.NewFlagSet("crud", flag.ContinueOnError)
+	flags.StringVar(&opts.SQL, "sql", "", "包含 CREATE TABLE 语句的SQL文件（必填）")
+	flags.StringVar(&tables, "table", "", "要生成的表，多个用逗号分隔；为空时生成文件中所有的表")
+	flags.StringVar(&opts.Project, "project", ".", "项目根目录，需要有 go.mod")
+	flags.StringVar(&opts.Name, "name", "", "Go中使用的名字，默认由表名转换，如 blog_post -> BlogPost；只能和一张表一起使用")
+	flags.StringVar(&opts.Auth, "auth", "admin", "写接口的鉴权：admin 使用 middlewares.AdminAuth，none 不鉴权")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if tables != "" {
+		opts.Tables = strings.Split(tables, ",")
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		flags.Usage()
+		return 2
+	}
+	if err := generateCRUD(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "generate crud failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+var goNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+func (o *crudOptions) validate() error {
+	switch {
+	case o.SQL == "":
+		return errors.New("-sql is required")
+	case o.Auth != "admin" && o.Auth != "none":
+		return fmt.Errorf("-auth must be admin or none, got %q", o.Auth)
+	case o.Name != "" && len(o.Tables) != 1:
+		return errors.New("-name needs exactly one -table")
+	case o.Name != "" && !goNamePattern.MatchString(o.Name):
+		return fmt.Errorf("invalid -name %q, use an exported Go name like BlogPost", o.Name)
+	}
+	return nil
+}
+
+// generateCRUD 先检查所有的表，都没有问题再写文件，避免只生成了一半
+func generateCRUD(o crudOptions) error {
+	sql, err := os.ReadFile(o.SQL)
+	if err != nil {
+		return err
+	}
+	tables, err := parseTables(string(sql))
+	if err != nil {
+		return fmt.Errorf("parse %s failed: %w", o.SQL, err)
+	}
+	tables, err = selectTables(tables, o.Tables)
+	if err != nil {
+		return err
+	}
+	p, err := loadProject(o.Project)
+	if err != nil {
+		return err
+	}
+	if err := p.require(o.Auth == "admin"); err != nil {
+		return err
+	}
+
+	var files []genFile
+	for _, t := range tables {
+		r, err := newResource(t, o, p)
+		if err != nil {
+			return err
+		}
+		fs, err := r.files(p)
+		if err != nil {
+			return err
+		}
+		if err := p.checkConflicts(r, fs); err != nil {
+			return err
+		}
+		files = append(files, fs...)
+	}
+	for _, f := range files {
+		dst := filepath.Join(p.dir, filepath.FromSlash(f.path))
+		if f.once {
+			if _, err := os.Stat(dst); err == nil {
+				fmt.Printf("skip   %s (already exists)\n", f.path)
+				continue
+			}
+		}
+		if err := os.WriteFile(dst, f.content, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("write  %s\n", f.path)
+	}
+	return nil
+}
+
+func selectTables(all []*table, names []string) ([]*table, error) {
+	if len(all) == 0 {
+		return nil, errors.New("no CREATE TABLE statement found")
+	}
+	if len(names) == 0 {
+		return all, nil
+	}
+	var tables []*table
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		var found *table
+		for _, t := range all {
+			if t.Name == name {
+				found = t
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("table %s not found", name)
+		}
+		tables = append(tables, found)
+	}
+	return tables, nil
+}
+
+func newResource(t *table, o crudOptions, p *project) (*resource, error) {
+	r := &resource{
+		Module: p.module,
+		Source: filepath.Base(o.SQL),
+		Table:  t.Name,
+		Name:   o.Name,
+
+		TableComment: t.Comment,
+		Logger:       p.hasLogger,
+		Auth:         o.Auth == "admin",
+	}
+	if r.Name == "" {
+		r.Name = goName(t.Name)
+	}
+	if !goNamePattern.MatchString(r.Name) {
+		return nil, fmt.Errorf("table %s: can not convert to a Go name, use -name", t.Name)
+	}
+	r.Plural = plural(r.Name)
+	r.Unexported = strings.ToLower(r.Name[:1]) + r.Name[1:]
+	r.Route = snakeCase(r.Name)
+	r.LogExpr = "zap.L()"
+	if p.hasLogger {
+		r.LogExpr = "logger.WithContext(c.Request.Context())"
+	}
+
+	key, err := t.key()
+	if err != nil {
+		return nil, err
+	}
+	// 表的业务主键是 xxx_id 时，自增的 id 列只在数据库内部使用，不放进模型
+	var hidden *column
+	for _, c := range t.Columns {
+		if c.AutoIncrement && c != key {
+			hidden = c
+		}
+	}
+
+	var inserts, updates []*field
+	for _, c := range t.Columns {
+		if c == hidden {
+			continue
+		}
+		f, err := newField(c)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", t.Name, err)
+		}
+		r.Fields = append(r.Fields, f)
+		if f.Default != "" {
+			r.Defaults = append(r.Defaults, f)
+		}
+		switch {
+		case c == key:
+			r.Key = f
+			r.KeyAuto = c.AutoIncrement
+			r.KeySnowflake = !c.AutoIncrement && p.snowflake != "" && strings.Contains(f.GoType, "int") && !f.Pointer
+			if r.KeyAuto {
+				continue
+			}
+			inserts = append(inserts, f)
+			if !r.KeySnowflake {
+				// 没有雪花算法时由调用方传入主键
+				r.Params = append(r.Params, f)
+			}
+		case c.generated():
+			// 数据库生成的时间不需要传
+		default:
+			inserts = append(inserts, f)
+			updates = append(updates, f)
+			r.Params = append(r.Params, f)
+		}
+	}
+	if r.Key.Pointer {
+		return nil, fmt.Errorf("table %s: key column %s must be NOT NULL", t.Name, r.Key.Column)
+	}
+	if r.KeySnowflake {
+		r.Snowflake = p.snowflake
+	}
+	r.buildSQL(inserts, updates)
+	return r, nil
+}
+
+// key 用来查询单条记录的列：优先 表名_id 这样的业务主键，其次是单列主键
+func (t *table) key() (*column, error) {
+	if c := t.column(t.Name + "_id"); c != nil && t.unique(c.Name) {
+		return c, nil
+	}
+	if len(t.Primary) == 1 {
+		return t.column(t.Primary[0]), nil
+	}
+	return nil, fmt.Errorf("table %s: need a single column primary key or a unique %s_id column", t.Name, t.Name)
+}
+
+// generated 由数据库填写的列，如 create_time、update_time
+func (c *column) generated() bool {
+	if c.OnUpdate {
+		return true
+	}
+	return c.Default != nil && strings.HasPrefix(strings.ToUpper(*c.Default), "CURRENT_TIMESTAMP")
+}
+
+func newField(c *column) (*field, error) {
+	base, err := goType(c)
+	if err != nil {
+		return nil, err
+	}
+	f := &field{
+		Column:  c.Name,
+		Name:    goName(c.Name),
+		GoType:  base,
+		Comment: c.Comment,
+	}
+	var rules []string
+	switch {
+	case !c.NotNull:
+		// 可以为NULL的列用指针，参数也是可选的
+		f.Pointer, f.Optional = true, true
+		f.GoType = "*" + base
+		f.ParamType = f.GoType
+		rules = append(rules, "omitempty")
+	case c.Default != nil || c.AutoIncrement:
+		// 有默认值的列参数是可选的，没有传时使用默认值
+		f.Optional = true
+		f.ParamType = "*" + base
+		rules = append(rules, "omitempty")
+		if c.Default != nil && !c.generated() {
+			f.Default = defaultLiteral(base, *c.Default)
+		}
+	default:
+		f.ParamType = base
+		rules = append(rules, "required")
+	}
+	switch {
+	case (c.Type == "varchar" || c.Type == "char") && len(c.Args) == 1:
+		rules = append(rules, "max="+c.Args[0])
+	case c.Type == "enum":
+		rules = append(rules, "oneof="+strings.Join(c.Args, " "))
+	}
+	if len(rules) > 1 || rules[0] == "required" {
+		f.Binding = strings.Join(rules, ",")
+	}
+	return f, nil
+}
+
+// goType MySQL类型对应的Go类型
+func goType(c *column) (string, error) {
+	sign := ""
+	if c.Unsigned {
+		sign = "u"
+	}
+	switch c.Type {
+	case "tinyint", "bool", "boolean":
+		return sign + "int8", nil
+	case "smallint", "year":
+		return sign + "int16", nil
+	case "mediumint", "int", "integer":
+		return sign + "int32", nil
+	case "bigint", "serial":
+		return sign + "int64", nil
+	case "float":
+		return "float32", nil
+	case "double", "real":
+		return "float64", nil
+	case "decimal", "numeric", "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json":
+		// decimal 用字符串避免精度丢失
+		return "string", nil
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit":
+		return "[]byte", nil
+	case "date", "datetime", "timestamp":
+		return "time.Time", nil
+	case "time":
+		return "string", nil
+	}
+	return "", fmt.Errorf("column %s: unsupported type %s", c.Name, c.Type)
+}
+
+// defaultLiteral 默认值转成Go字面量，转换不了时返回空字符串，使用零值
+func defaultLiteral(goType, v string) string {
+	switch {
+	case goType == "string":
+		return strconv.Quote(v)
+	case strings.Contains(goType, "int"), strings.HasPrefix(goType, "float"):
+		if _, err := strconv.ParseFloat(v, 64); err == nil && v != "0" {
+			return v
+		}
+	}
+	return ""
+}
+
+func quoteIdent(s string) string { return "`" + s + "`" }
+
+func (r *resource) buildSQL(inserts, updates []*field) {
+	cols := make([]string, len(r.Fields))
+	for i, f := range r.Fields {
+		cols[i] = quoteIdent(f.Column)
+	}
+	table, key := quoteIdent(r.Table), quoteIdent(r.Key.Column)
+	r.ColumnsSQL = strings.Join(cols, ", ")
+
+	var names, marks, args []string
+	for _, f := range inserts {
+		names = append(names, quoteIdent(f.Column))
+		marks = append(marks, "?")
+		args = append(args, "d."+f.Name)
+	}
+	r.InsertSQL = fmt.Sprintf("insert into %s(%s) values(%s)", table, strings.Join(names, ", "), strings.Join(marks, ", "))
+	r.InsertArgs = strings.Join(args, ", ")
+	r.GetSQL = fmt.Sprintf(" from %s where %s = ?", table, key)
+	r.ListSQL = fmt.Sprintf(" from %s order by %s desc limit ?, ?", table, key)
+	r.DeleteSQL = fmt.Sprintf("delete from %s where %s = ?", table, key)
+
+	if len(updates) > 0 {
+		var sets []string
+		args = args[:0]
+		for _, f := range updates {
+			sets = append(sets, quoteIdent(f.Column)+" = ?")
+			args = append(args, "d."+f.Name)
+		}
+		r.UpdateSQL = fmt.Sprintf("update %s set %s where %s = ?", table, strings.Join(sets, ", "), key)
+		r.UpdateArgs = strings.Join(append(args, "d."+r.Key.Name), ", ")
+	}
+}
+
+// genFile 一个要写入的文件，once 为true时已经存在就不覆盖
+type genFile struct {
+	path    string
+	content []byte
+	once    bool
+	pkg     string   // 所在的包目录
+	names   []string // 文件中声明的顶层名字，用于检查冲突
+}
+
+func (r *resource) files(p *project) ([]genFile, error) {
+	base := r.Route
+	specs := []struct {
+		path, pkg string
+		tmpl      string
+		once      bool
+		names     []string
+	}{
+		{"models/" + base + "_gen.go", "models", "models", false,
+			[]string{r.Name, "New" + r.Name, "Param" + r.Name, "Param" + r.Name + "List"}},
+		{"dao/mysql/" + base + "_gen.go", "dao/mysql", "dao", false,
+			[]string{"Error" + r.Name + "NotExist", r.Unexported + "Columns", "Create" + r.Name, "Get" + r.Name + "ByID",
+				"List" + r.Plural, "Update" + r.Name, "Delete" + r.Name}},
+		{"logic/" + base + ".go", "logic", "logic", true,
+			[]string{"Create" + r.Name, "Get" + r.Name, "List" + r.Plural, "Update" + r.Name, "Delete" + r.Name}},
+		{"controller/" + base + "_gen.go", "controller", "controller", false,
+			[]string{"Create" + r.Name + "Handler", r.Name + "DetailHandler", r.Name + "ListHandler",
+				"Update" + r.Name + "Handler", "Delete" + r.Name + "Handler", "parse" + r.Name + "ID"}},
+		{"routes/" + base + "_gen.go", "routes", "routes", false, nil},
+	}
+	var files []genFile
+	for _, s := range specs {
+		var buf bytes.Buffer
+		if err := crudTemplates.ExecuteTemplate(&buf, s.tmpl, r); err != nil {
+			return nil, fmt.Errorf("table %s: render %s failed: %w", r.Table, s.path, err)
+		}
+		content, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("table %s: format %s failed: %w\n%s", r.Table, s.path, err, buf.Bytes())
+		}
+		files = append(files, genFile{path: s.path, content: content, once: s.once, pkg: s.pkg, names: s.names})
+	}
+	return files, nil
+}
+
+// project 目标项目中生成代码依赖的东西
+type project struct {
+	dir       string
+	module    string
+	decls     map[string]map[string]string // 包目录 -> 顶层名字 -> 声明所在的文件
+	hasLogger bool
+	snowflake string
+}
+
+var modulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+func loadProject(dir string) (*project, error) {
+	gomod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("read go.mod failed: %w", err)
+	}
+	m := modulePattern.FindSubmatch(gomod)
+	if m == nil {
+		return nil, errors.New("no module directive in go.mod")
+	}
+	p := &project{dir: dir, module: string(m[1]), decls: map[string]map[string]string{}}
+	for _, pkg := range []string{"models", "dao/mysql", "logic", "controller", "routes", "logger", "middlewares"} {
+		if p.decls[pkg], err = topLevelDecls(filepath.Join(dir, filepath.FromSlash(pkg))); err != nil {
+			return nil, err
+		}
+	}
+	_, p.hasLogger = p.decls["logger"]["WithContext"]
+
+	// 雪花算法的包，目录名不一定是 snowflake
+	dirs, _ := filepath.Glob(filepath.Join(dir, "pkg", "*"))
+	sort.Strings(dirs)
+	for _, d := range dirs {
+		decls, err := topLevelDecls(d)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := decls["GetID"]; ok && strings.Contains(strings.ToLower(d), "flake") {
+			p.snowflake = path.Join(p.module, "pkg", filepath.Base(d))
+			break
+		}
+	}
+	return p, nil
+}
+
+// require 检查生成的代码依赖的函数是否存在
+func (p *project) require(auth bool) error {
+	needs := []struct{ pkg, name, hint string }{
+		{"dao/mysql", "DB", "func DB() Querier"},
+		{"dao/mysql", "Querier", "type Querier interface"},
+		{"dao/mysql", "WithPrimary", "func WithPrimary(ctx) context.Context"},
+		{"controller", "invalidParam", "func invalidParam(c *gin.Context, err error)"},
+		{"routes", "register", "route registry func register(func(r *gin.Engine))"},
+	}
+	if auth {
+		needs = append(needs, struct{ pkg, name, hint string }{"middlewares", "AdminAuth", "func AdminAuth() gin.HandlerFunc, or use -auth none"})
+	}
+	for _, n := range needs {
+		if _, ok := p.decls[n.pkg][n.name]; !ok {
+			// gen project 生成的项目也会走到这里，模板中还没有这些
+			return fmt.Errorf("%s: %s not found, generated code needs %s (see forumProject; projects from gen project do not have it yet)", n.pkg, n.name, n.hint)
+		}
+	}
+	return nil
+}
+
+// checkConflicts 生成的名字和路由不能和手写的代码重复；之前生成的文件会被覆盖，不算冲突
+func (p *project) checkConflicts(r *resource, files []genFile) error {
+	var conflicts []string
+	for _, f := range files {
+		own := f.path
+		if f.once {
+			content, err := os.ReadFile(filepath.Join(p.dir, filepath.FromSlash(f.path)))
+			if err == nil && !bytes.Contains(content, []byte(stubMarker)) {
+				return fmt.Errorf("%s already exists and was not generated by gen crud, use -name", f.path)
+			}
+			if err == nil {
+				// 之前生成的，保留不覆盖，里面的名字是自己的
+				continue
+			}
+		}
+		for _, name := range f.names {
+			if file, ok := p.decls[f.pkg][name]; ok && file != path.Base(own) {
+				conflicts = append(conflicts, fmt.Sprintf("%s.%s already declared in %s/%s", path.Base(f.pkg), name, f.pkg, file))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("table %s conflicts with existing code, use -name to choose another name:\n  %s", r.Table, strings.Join(conflicts, "\n  "))
+	}
+
+	// 路由重复时gin在启动时panic
+	routes, _ := filepath.Glob(filepath.Join(p.dir, "routes", "*.go"))
+	for _, file := range routes {
+		if filepath.Base(file) == r.Route+"_gen.go" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(content, []byte(`"/`+r.Route+`"`)) || bytes.Contains(content, []byte(`"/`+r.Route+`/:`)) {
+			return fmt.Errorf("route /%s already registered in routes/%s, use -name", r.Route, filepath.Base(file))
+		}
+	}
+	return nil
+}
+
+// topLevelDecls 包中所有的顶层名字（不含方法），目录不存在时返回空
+func topLevelDecls(dir string) (map[string]string, error) {
+	decls := map[string]string{}
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		base := filepath.Base(file)
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					decls[d.Name.Name] = base
+				}
+			case *ast.GenDecl:
+				for _, s := range d.Specs {
+					switch s := s.(type) {
+					case *ast.TypeSpec:
+						decls[s.Name.Name] = base
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							decls[n.Name] = base
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls, nil
+}
+
+// initialisms 转成Go名字时全部大写的缩写
+var initialisms = map[string]bool{"id": true, "ip": true, "url": true, "uri": true, "uuid": true, "api": true, "http": true, "json": true, "sql": true, "html": true}
+
+// goName blog_post_id -> BlogPostID
+func goName(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+	for i, p := range parts {
+		if initialisms[strings.ToLower(p)] {
+			parts[i] = strings.ToUpper(p)
+			continue
+		}
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// snakeCase BlogPostID -> blog_post_id
+func snakeCase(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := rs[i-1] >= 'a' && rs[i-1] <= 'z' || rs[i-1] >= '0' && rs[i-1] <= '9'
+			nextLower := i+1 < len(rs) && rs[i+1] >= 'a' && rs[i+1] <= 'z'
+			if prevLower || (nextLower && rs[i-1] >= 'A' && rs[i-1] <= 'Z') {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteString(strings.ToLower(string(r)))
+	}
+	return b.String()
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// crudTemplates 生成的代码和项目中手写的分层保持一致：models、dao/mysql、logic、controller、routes
+var crudTemplates = template.Must(template.New("crud").Funcs(template.FuncMap{"dict": dict}).Parse(`
+{{define "header"}}// Code generated by gen crud from {{.Source}}. DO NOT EDIT.
+{{end}}
+
+{{define "models"}}{{template "header" .}}
+package models
+{{if .ModelTime}}
+import "time"
+{{end}}
+// {{.Name}} {{.Table}} 表{{with .TableComment}}，{{.}}{{end}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}} ` + "`" + `json:"{{.Column}}" db:"{{.Column}}"` + "`" + `{{with .Comment}} // {{.}}{{end}}
+{{- end}}
+}
+
+// New{{.Name}} 按建表语句中的默认值创建
+func New{{.Name}}() *{{.Name}} {
+	return &{{.Name}}{
+{{- range .Defaults}}
+		{{.Name}}: {{.Default}},
+{{- end}}
+	}
+}
+
+// Param{{.Name}} 创建和修改时的参数，可选的字段没有传时，创建使用默认值，修改保持原来的值
+type Param{{.Name}} struct {
+{{- range .Params}}
+	{{.Name}} {{.ParamType}} ` + "`" + `json:"{{.Column}}"{{with .Binding}} binding:"{{.}}"{{end}}` + "`" + `
+{{- end}}
+}
+
+// Apply 把参数写入d
+func (p *Param{{.Name}}) Apply(d *{{.Name}}) {
+{{- range .Params}}
+{{- if .ParamPointer}}
+	if p.{{.Name}} != nil {
+		d.{{.Name}} = {{if not .Pointer}}*{{end}}p.{{.Name}}
+	}
+{{- else}}
+	d.{{.Name}} = p.{{.Name}}
+{{- end}}
+{{- end}}
+}
+
+// Param{{.Name}}List 列表的分页参数
+type Param{{.Name}}List struct {
+	Page int ` + "`" + `form:"page" binding:"omitempty,min=1"` + "`" + `
+	Size int ` + "`" + `form:"size" binding:"omitempty,min=1,max=100"` + "`" + `
+}
+{{end}}
+
+{{define "dao"}}{{template "header" .}}
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"{{.Module}}/models"
+)
+
+var Error{{.Name}}NotExist = errors.New("记录不存在")
+
+const {{.Unexported}}Columns = {{printf "%q" .ColumnsSQL}}
+
+{{if .KeyAuto -}}
+// Create{{.Name}} 插入后把自增的 {{.Key.Column}} 写回d
+func Create{{.Name}}(ctx context.Context, q Querier, d *models.{{.Name}}) error {
+	sqlStr := {{printf "%q" .InsertSQL}}
+	res, err := q.ExecContext(ctx, sqlStr{{with .InsertArgs}}, {{.}}{{end}})
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.{{.Key.Name}} = {{.Key.GoType}}(id)
+	return nil
+}
+{{- else -}}
+func Create{{.Name}}(ctx context.Context, q Querier, d *models.{{.Name}}) error {
+	sqlStr := {{printf "%q" .InsertSQL}}
+	_, err := q.ExecContext(ctx, sqlStr, {{.InsertArgs}})
+	return err
+}
+{{- end}}
+
+func Get{{.Name}}ByID(ctx context.Context, q Querier, id {{.Key.GoType}}) (*models.{{.Name}}, error) {
+	d := new(models.{{.Name}})
+	sqlStr := "select " + {{.Unexported}}Columns + {{printf "%q" .GetSQL}}
+	err := q.GetContext(ctx, d, sqlStr, id)
+	if err == sql.ErrNoRows {
+		return nil, Error{{.Name}}NotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// List{{.Plural}} 按 {{.Key.Column}} 倒序分页
+func List{{.Plural}}(ctx context.Context, q Querier, offset, limit int) ([]*models.{{.Name}}, error) {
+	list := make([]*models.{{.Name}}, 0, limit)
+	sqlStr := "select " + {{.Unexported}}Columns + {{printf "%q" .ListSQL}}
+	err := q.SelectContext(ctx, &list, sqlStr, offset, limit)
+	return list, err
+}
+{{if .UpdateSQL}}
+func Update{{.Name}}(ctx context.Context, q Querier, d *models.{{.Name}}) error {
+	sqlStr := {{printf "%q" .UpdateSQL}}
+	_, err := q.ExecContext(ctx, sqlStr, {{.UpdateArgs}})
+	return err
+}
+{{end}}
+func Delete{{.Name}}(ctx context.Context, q Querier, id {{.Key.GoType}}) error {
+	sqlStr := {{printf "%q" .DeleteSQL}}
+	res, err := q.ExecContext(ctx, sqlStr, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return Error{{.Name}}NotExist
+	}
+	return nil
+}
+{{end}}
+
+{{define "logic"}}package logic
+
+` + stubMarker + `
+// 根据 {{.Table}} 表生成的业务逻辑，需要校验权限、清缓存等时在这里修改
+
+import (
+	"context"
+	"{{.Module}}/dao/mysql"
+	"{{.Module}}/models"
+{{- if .KeySnowflake}}
+	snowflake "{{.Snowflake}}"
+{{- end}}
+)
+
+func Create{{.Name}}(ctx context.Context, p *models.Param{{.Name}}) (*models.{{.Name}}, error) {
+	d := models.New{{.Name}}()
+	p.Apply(d)
+{{- if .KeySnowflake}}
+	id, err := snowflake.GetID()
+	if err != nil {
+		return nil, err
+	}
+	d.{{.Key.Name}} = {{if ne .Key.GoType "uint64"}}{{.Key.GoType}}(id){{else}}id{{end}}
+{{- end}}
+	if err := mysql.Create{{.Name}}(ctx, mysql.DB(), d); err != nil {
+		return nil, err
+	}
+	// 重新读一次，拿到数据库填写的字段；刚写入的数据从主库读
+	return mysql.Get{{.Name}}ByID(mysql.WithPrimary(ctx), mysql.DB(), d.{{.Key.Name}})
+}
+
+func Get{{.Name}}(ctx context.Context, id {{.Key.GoType}}) (*models.{{.Name}}, error) {
+	return mysql.Get{{.Name}}ByID(ctx, mysql.DB(), id)
+}
+
+func List{{.Plural}}(ctx context.Context, p *models.Param{{.Name}}List) ([]*models.{{.Name}}, error) {
+	page, size := p.Page, p.Size
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 10
+	}
+	return mysql.List{{.Plural}}(ctx, mysql.DB(), (page-1)*size, size)
+}
+{{if .UpdateSQL}}
+func Update{{.Name}}(ctx context.Context, id {{.Key.GoType}}, p *models.Param{{.Name}}) (*models.{{.Name}}, error) {
+	ctx = mysql.WithPrimary(ctx)
+	d, err := mysql.Get{{.Name}}ByID(ctx, mysql.DB(), id)
+	if err != nil {
+		return nil, err
+	}
+	p.Apply(d)
+	d.{{.Key.Name}} = id
+	if err := mysql.Update{{.Name}}(ctx, mysql.DB(), d); err != nil {
+		return nil, err
+	}
+	return mysql.Get{{.Name}}ByID(ctx, mysql.DB(), id)
+}
+{{end}}
+func Delete{{.Name}}(ctx context.Context, id {{.Key.GoType}}) error {
+	return mysql.Delete{{.Name}}(ctx, mysql.DB(), id)
+}
+{{end}}
+
+{{define "bind"}}
+		{{.R.LogExpr}}.Error("{{.Handler}} with invalid param", zap.Error(err))
+		invalidParam(c, err)
+		return
+{{- end}}
+
+{{define "notExist"}}
+	if errors.Is(err, mysql.Error{{.Name}}NotExist) {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "记录不存在",
+		})
+		return
+	}
+{{- end}}
+
+{{define "busy"}}
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "服务繁忙",
+		})
+		return
+{{- end}}
+
+{{define "controller"}}{{template "header" .}}
+package controller
+
+import (
+	"errors"
+	"{{.Module}}/dao/mysql"
+{{- if .Logger}}
+	"{{.Module}}/logger"
+{{- end}}
+	"{{.Module}}/logic"
+	"{{.Module}}/models"
+	"net/http"
+{{- if ne .Key.GoType "string"}}
+	"strconv"
+{{- end}}
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Create{{.Name}}Handler 创建
+func Create{{.Name}}Handler(c *gin.Context) {
+
+	// 1. 获取参数和参数校验
+	p := new(models.Param{{.Name}})
+	if err := c.ShouldBindJSON(p); err != nil {
+		{{- template "bind" (dict "R" . "Handler" (print "Create" .Name))}}
+	}
+
+	// 2. 业务逻辑
+	d, err := logic.Create{{.Name}}(c.Request.Context(), p)
+	if err != nil {
+		{{.LogExpr}}.Error("logic.Create{{.Name}} failed", zap.Error(err))
+		{{- template "busy"}}
+	}
+
+	// 3. 返回值
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": d,
+	})
+}
+
+// {{.Name}}DetailHandler 详情
+func {{.Name}}DetailHandler(c *gin.Context) {
+	id, ok := parse{{.Name}}ID(c)
+	if !ok {
+		return
+	}
+
+	d, err := logic.Get{{.Name}}(c.Request.Context(), id)
+	{{- template "notExist" .}}
+	if err != nil {
+		{{.LogExpr}}.Error("logic.Get{{.Name}} failed", {{.KeyZap}}, zap.Error(err))
+		{{- template "busy"}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": d,
+	})
+}
+
+// {{.Name}}ListHandler 分页列表，?page=1&size=10
+func {{.Name}}ListHandler(c *gin.Context) {
+	p := new(models.Param{{.Name}}List)
+	if err := c.ShouldBindQuery(p); err != nil {
+		{{- template "bind" (dict "R" . "Handler" (print .Name "List"))}}
+	}
+
+	list, err := logic.List{{.Plural}}(c.Request.Context(), p)
+	if err != nil {
+		{{.LogExpr}}.Error("logic.List{{.Plural}} failed", zap.Error(err))
+		{{- template "busy"}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": list,
+	})
+}
+{{if .UpdateSQL}}
+// Update{{.Name}}Handler 修改
+func Update{{.Name}}Handler(c *gin.Context) {
+	id, ok := parse{{.Name}}ID(c)
+	if !ok {
+		return
+	}
+	p := new(models.Param{{.Name}})
+	if err := c.ShouldBindJSON(p); err != nil {
+		{{- template "bind" (dict "R" . "Handler" (print "Update" .Name))}}
+	}
+
+	d, err := logic.Update{{.Name}}(c.Request.Context(), id, p)
+	{{- template "notExist" .}}
+	if err != nil {
+		{{.LogExpr}}.Error("logic.Update{{.Name}} failed", {{.KeyZap}}, zap.Error(err))
+		{{- template "busy"}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":  "success",
+		"data": d,
+	})
+}
+{{end}}
+// Delete{{.Name}}Handler 删除
+func Delete{{.Name}}Handler(c *gin.Context) {
+	id, ok := parse{{.Name}}ID(c)
+	if !ok {
+		return
+	}
+
+	err := logic.Delete{{.Name}}(c.Request.Context(), id)
+	{{- template "notExist" .}}
+	if err != nil {
+		{{.LogExpr}}.Error("logic.Delete{{.Name}} failed", {{.KeyZap}}, zap.Error(err))
+		{{- template "busy"}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg": "success",
+	})
+}
+
+// parse{{.Name}}ID 解析路径中的 {{.Key.Column}}，无效时直接返回错误响应
+func parse{{.Name}}ID(c *gin.Context) ({{.Key.GoType}}, bool) {
+	{{.ParseKey}}
+	if err != nil || id == {{if eq .Key.GoType "string"}}""{{else}}0{{end}} {
+		c.JSON(http.StatusOK, gin.H{
+			"msg": "无效的ID",
+		})
+		return id, false
+	}
+	return id, true
+}
+{{end}}
+
+{{define "routes"}}{{template "header" .}}
+package routes
+
+import (
+	"{{.Module}}/controller"
+{{- if .Auth}}
+	"{{.Module}}/middlewares"
+{{- end}}
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	register(func(r *gin.Engine) {
+		r.GET("/{{.Route}}", controller.{{.Name}}ListHandler)
+		r.GET("/{{.Route}}/:id", controller.{{.Name}}DetailHandler)
+		r.POST("/{{.Route}}", {{if .Auth}}middlewares.AdminAuth(), {{end}}controller.Create{{.Name}}Handler)
+{{- if .UpdateSQL}}
+		r.PUT("/{{.Route}}/:id", {{if .Auth}}middlewares.AdminAuth(), {{end}}controller.Update{{.Name}}Handler)
+{{- end}}
+		r.DELETE("/{{.Route}}/:id", {{if .Auth}}middlewares.AdminAuth(), {{end}}controller.Delete{{.Name}}Handler)
+	})
+}
+{{end}}
+`))
+
+// dict 给子模板传多个参数：dict "R" . "Handler" "Create"
+func dict(kv ...interface{}) (map[string]interface{}, error) {
+	if len(kv)%2 != 0 {
+		return nil, fmt.Errorf("dict needs key value pairs")
+	}
+	m := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		m[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+	return m, nil
+}
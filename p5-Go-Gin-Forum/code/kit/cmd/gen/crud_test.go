@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "重新生成 testdata/golden 中的文件")
+
+// newTestProject 把 testdata/project 复制到临时目录，返回项目目录和 create_table.sql 加上 testdata/extra.sql 的SQL文件
+func newTestProject(t *testing.T) (dir, sqlFile string) {
+	t.Helper()
+	dir = t.TempDir()
+	err := filepath.WalkDir("testdata/project", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel("testdata/project", p)
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0o755)
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), content, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"models", "logic"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	forum, err := os.ReadFile("../../../forumProject/models/create_table.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra, err := os.ReadFile("testdata/extra.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 文件名会写进生成的代码，和项目中的保持一致
+	sqlFile = filepath.Join(t.TempDir(), "create_table.sql")
+	if err := os.WriteFile(sqlFile, append(append(forum, '\n'), extra...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, sqlFile
+}
+
+// generated 项目中 testdata/project 以外的文件，路径 -> 内容
+func generated(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		if _, err := os.Stat(filepath.Join("testdata/project", rel)); err == nil {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		files[filepath.ToSlash(rel)] = content
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func goldenPath(rel string) string {
+	return filepath.Join("testdata", "golden", filepath.FromSlash(rel)+".golden")
+}
+
+func TestCRUDGolden(t *testing.T) {
+	dir, sqlFile := newTestProject(t)
+	if err := generateCRUD(crudOptions{SQL: sqlFile, Project: dir, Auth: "admin"}); err != nil {
+		t.Fatalf("generateCRUD: %v", err)
+	}
+	files := generated(t, dir)
+
+	if *update {
+		os.RemoveAll(filepath.Join("testdata", "golden"))
+		for rel, content := range files {
+			p := goldenPath(rel)
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(p, content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var goldens []string
+	filepath.WalkDir(filepath.Join("testdata", "golden"), func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(filepath.Join("testdata", "golden"), p)
+			goldens = append(goldens, strings.TrimSuffix(filepath.ToSlash(rel), ".golden"))
+		}
+		return nil
+	})
+	var names []string
+	for rel := range files {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+	sort.Strings(goldens)
+	if strings.Join(names, "\n") != strings.Join(goldens, "\n") {
+		t.Fatalf("generated files:\n%s\nwant:\n%s\nrun go test -update to accept the change", strings.Join(names, "\n"), strings.Join(goldens, "\n"))
+	}
+	for _, rel := range names {
+		want, _ := os.ReadFile(goldenPath(rel))
+		if !bytes.Equal(files[rel], want) {
+			t.Errorf("%s differs from %s, run go test -update to accept the change\n%s", rel, goldenPath(rel), files[rel])
+		}
+	}
+}
+
+func TestCRUDRerun(t *testing.T) {
+	dir, sqlFile := newTestProject(t)
+	opts := crudOptions{SQL: sqlFile, Tables: []string{"tag"}, Project: dir, Auth: "admin"}
+	if err := generateCRUD(opts); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	first := generated(t, dir)
+
+	// 改动生成的文件和只生成一次的logic文件
+	gen := filepath.Join(dir, "models", "tag_gen.go")
+	stub := filepath.Join(dir, "logic", "tag.go")
+	if err := os.WriteFile(gen, []byte("package models\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edited := append(append([]byte{}, first["logic/tag.go"]...), "\n// 手写的改动\n"...)
+	if err := os.WriteFile(stub, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generateCRUD(opts); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	second := generated(t, dir)
+	for rel, content := range first {
+		if rel == "logic/tag.go" {
+			continue
+		}
+		if !bytes.Equal(second[rel], content) {
+			t.Errorf("%s should be overwritten with the same content", rel)
+		}
+	}
+	if !bytes.Equal(second["logic/tag.go"], edited) {
+		t.Error("logic/tag.go with the stub marker should be kept")
+	}
+
+	// 同名的手写文件没有标记，不能覆盖
+	if err := os.WriteFile(stub, []byte("package logic\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateCRUD(opts); err == nil || !strings.Contains(err.Error(), "not generated by gen crud") {
+		t.Errorf("err = %v, want hand-written logic/tag.go rejected", err)
+	}
+}
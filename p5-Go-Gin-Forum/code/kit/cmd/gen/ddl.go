@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// table 从 CREATE TABLE 语句中解析出的表结构，只保留生成代码用得到的部分
+type table struct {
+	Name    string
+	Comment string
+	Columns []*column
+	Primary []string   // 主键的列
+	Uniques [][]string // 唯一索引的列
+}
+
+type column struct {
+	Name          string
+	Type          string   // 小写，如 bigint、varchar
+	Args          []string // 类型参数，如 varchar(64) 中的 64，enum 的取值
+	Unsigned      bool
+	NotNull       bool
+	Default       *string // 为nil表示没有默认值，DEFAULT NULL 也算没有
+	AutoIncrement bool
+	OnUpdate      bool // ON UPDATE CURRENT_TIMESTAMP
+	Comment       string
+}
+
+func (t *table) column(name string) *column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// unique 是否是单列的主键或唯一索引
+func (t *table) unique(name string) bool {
+	if len(t.Primary) == 1 && t.Primary[0] == name {
+		return true
+	}
+	for _, u := range t.Uniques {
+		if len(u) == 1 && u[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlToken 词法单元，标识符和字符串都已去掉引号
+type sqlToken struct {
+	text   string
+	quoted bool // 反引号标识符或字符串，不能当关键字
+}
+
+func (t sqlToken) is(keyword string) bool {
+	return !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+// tokenize 把SQL切成词法单元，跳过注释
+func tokenize(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	rs := []rune(sql)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-', r == '#':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			j := i + 2
+			for j+1 < len(rs) && !(rs[j] == '*' && rs[j+1] == '/') {
+				j++
+			}
+			if j+1 >= len(rs) {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i = j + 2
+		case r == '`' || r == '\'' || r == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(rs); j++ {
+				if rs[j] == '\\' && r != '`' && j+1 < len(rs) {
+					j++
+					b.WriteRune(rs[j])
+					continue
+				}
+				if rs[j] == r {
+					// 两个引号表示引号本身
+					if j+1 < len(rs) && rs[j+1] == r {
+						b.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated quote %c", r)
+			}
+			tokens = append(tokens, sqlToken{text: b.String(), quoted: true})
+			i = j + 1
+		case strings.ContainsRune("(),;=", r):
+			tokens = append(tokens, sqlToken{text: string(r)})
+			i++
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune("(),;=`'\"", rs[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: string(rs[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parseTables 解析SQL文件中所有的 CREATE TABLE 语句，其他语句忽略
+func parseTables(sql string) ([]*table, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	var tables []*table
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].is("CREATE") || i+1 >= len(tokens) || !tokens[i+1].is("TABLE") {
+			continue
+		}
+		t, next, err := parseCreateTable(tokens, i+2)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+		i = next
+	}
+	return tables, nil
+}
+
+func parseCreateTable(tokens []sqlToken, i int) (*table, int, error) {
+	if i+2 < len(tokens) && tokens[i].is("IF") && tokens[i+1].is("NOT") && tokens[i+2].is("EXISTS") {
+		i += 3
+	}
+	if i >= len(tokens) {
+		return nil, i, fmt.Errorf("missing table name")
+	}
+	t := &table{Name: tokens[i].text}
+	// 库名.表名
+	if dot := strings.LastIndexByte(t.Name, '.'); dot >= 0 && !tokens[i].quoted {
+		t.Name = strings.Trim(t.Name[dot+1:], "`")
+	}
+	i++
+	if i >= len(tokens) || tokens[i].text != "(" {
+		return nil, i, fmt.Errorf("table %s: expected (", t.Name)
+	}
+	// 按顶层的逗号切分出每一列和每个索引的定义
+	var (
+		depth int
+		item  []sqlToken
+	)
+	for i++; i < len(tokens); i++ {
+		tk := tokens[i]
+		if !tk.quoted {
+			switch tk.text {
+			case "(":
+				depth++
+			case ")":
+				if depth == 0 {
+					if err := t.addDefinition(item); err != nil {
+						return nil, i, err
+					}
+					// 表选项中只关心注释，到分号为止
+					for ; i < len(tokens) && tokens[i].text != ";"; i++ {
+						if tokens[i].is("COMMENT") {
+							if i+1 < len(tokens) && tokens[i+1].text == "=" {
+								i++
+							}
+							if i+1 < len(tokens) {
+								t.Comment = tokens[i+1].text
+							}
+						}
+					}
+					return t, i, t.check()
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					if err := t.addDefinition(item); err != nil {
+						return nil, i, err
+					}
+					item = nil
+					continue
+				}
+			}
+		}
+		item = append(item, tk)
+	}
+	return nil, i, fmt.Errorf("table %s: missing )", t.Name)
+}
+
+func (t *table) check() error {
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("table %s has no columns", t.Name)
+	}
+	for _, name := range t.Primary {
+		if t.column(name) == nil {
+			return fmt.Errorf("table %s: primary key column %s not found", t.Name, name)
+		}
+	}
+	return nil
+}
+
+// addDefinition 一列或一个索引的定义
+func (t *table) addDefinition(def []sqlToken) error {
+	if len(def) == 0 {
+		return nil
+	}
+	first := def[0]
+	switch {
+	case first.is("PRIMARY"):
+		t.Primary = indexColumns(def)
+	case first.is("UNIQUE"):
+		t.Uniques = append(t.Uniques, indexColumns(def))
+	case first.is("KEY"), first.is("INDEX"), first.is("FULLTEXT"), first.is("SPATIAL"),
+		first.is("CONSTRAINT"), first.is("FOREIGN"), first.is("CHECK"):
+		// 普通索引和约束与生成的代码无关
+	default:
+		c, err := parseColumn(def)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", t.Name, err)
+		}
+		t.Columns = append(t.Columns, c.column)
+		if c.primary {
+			t.Primary = []string{c.Name}
+		}
+		if c.unique {
+			t.Uniques = append(t.Uniques, []string{c.Name})
+		}
+	}
+	return nil
+}
+
+// indexColumns 索引定义中第一对括号里的列名，忽略前缀长度和排序
+func indexColumns(def []sqlToken) []string {
+	var cols []string
+	depth := 0
+	for _, tk := range def {
+		if !tk.quoted && tk.text == "(" {
+			depth++
+			continue
+		}
+		if !tk.quoted && tk.text == ")" {
+			depth--
+			if depth == 0 {
+				break
+			}
+			continue
+		}
+		if depth == 1 && (tk.quoted || (tk.text != "," && !tk.is("ASC") && !tk.is("DESC"))) {
+			cols = append(cols, tk.text)
+		}
+	}
+	return cols
+}
+
+// columnDef 解析中间状态，列级的 PRIMARY KEY 和 UNIQUE
+type columnDef struct {
+	*column
+	primary, unique bool
+}
+
+func parseColumn(def []sqlToken) (columnDef, error) {
+	if len(def) < 2 {
+		return columnDef{}, fmt.Errorf("invalid column definition %q", def[0].text)
+	}
+	c := columnDef{column: &column{Name: def[0].text, Type: strings.ToLower(def[1].text)}}
+	i := 2
+	// 类型参数
+	if i < len(def) && def[i].text == "(" && !def[i].quoted {
+		for i++; i < len(def) && !(def[i].text == ")" && !def[i].quoted); i++ {
+			if def[i].text != "," || def[i].quoted {
+				c.Args = append(c.Args, def[i].text)
+			}
+		}
+		i++
+	}
+	for ; i < len(def); i++ {
+		tk := def[i]
+		switch {
+		case tk.is("UNSIGNED"):
+			c.Unsigned = true
+		case tk.is("NOT") && i+1 < len(def) && def[i+1].is("NULL"):
+			c.NotNull = true
+			i++
+		case tk.is("AUTO_INCREMENT"):
+			c.AutoIncrement = true
+		case tk.is("PRIMARY"):
+			c.primary = true
+		case tk.is("UNIQUE"):
+			c.unique = true
+		case tk.is("DEFAULT") && i+1 < len(def):
+			i++
+			if !def[i].is("NULL") {
+				v := def[i].text
+				// CURRENT_TIMESTAMP() 之类的函数调用
+				if i+2 < len(def) && def[i+1].text == "(" && !def[i+1].quoted {
+					for i++; i < len(def) && def[i].text != ")"; i++ {
+					}
+				}
+				c.Default = &v
+			}
+		case tk.is("ON") && i+1 < len(def) && def[i+1].is("UPDATE"):
+			c.OnUpdate = true
+			i += 2
+		case tk.is("COMMENT") && i+1 < len(def):
+			i++
+			c.Comment = def[i].text
+		}
+	}
+	return c, nil
+}
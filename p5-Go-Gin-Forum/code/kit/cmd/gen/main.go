@@ -0,0 +1,40 @@
+// gen 代码生成工具
+//
+//	go run ./cmd/gen project -module example.com/blog -out ../blog -resource article
+//	go run ./cmd/gen crud -project ../forumProject -sql ../forumProject/models/create_table.sql -table tag
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// 子命令的用法说明
+const usage = `usage: gen <command> [flags]
+
+commands:
+  project   根据 base/projectTemplate 生成新项目，-h 查看参数
+  crud      根据 CREATE TABLE 语句生成增删改查的代码，可以重复执行，-h 查看参数
+            目标项目需要有 mysql.DB、mysql.Querier、mysql.WithPrimary、controller 中的 invalidParam
+            和 routes 中的 register（参考 forumProject）；gen project 生成的项目还没有这些，需要先加上
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var code int
+	switch os.Args[1] {
+	case "project":
+		code = projectCommand(os.Args[2:])
+	case "crud":
+		code = crudCommand(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		code = 2
+	}
+	os.Exit(code)
+}
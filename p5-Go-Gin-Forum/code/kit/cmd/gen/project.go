@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// templateModule 模板项目的模块名，生成时替换成 -module
+const templateModule = "forumProject"
+
+// templateResource 模板中示例资源的名字，生成时替换成 -resource
+const templateResource = "demo"
+
+// projectOptions 生成参数，组件开关的key和模板中 gen:if 后面的名字一致
+type projectOptions struct {
+	Template   string
+	Out        string
+	Module     string
+	Port       int
+	Resource   string // 为空时不生成示例CRUD
+	Components map[string]bool
+	Force      bool
+	Tidy       bool
+}
+
+// componentFiles 只属于某个组件的文件或目录，组件没有选择时整个跳过
+// 按顺序匹配第一个，目录中属于其他组件的文件要放在目录前面
+var componentFiles = []struct {
+	path, component string
+}{
+	{"dao/mysql/demo.go", "resource"},
+	{"dao/mysql", "mysql"},
+	{"dao/redis", "redis"},
+	{"pkg/snowflake", "snowflake"},
+	{"controller/validator.go", "validator"},
+	{"controller/response.go", "resource"},
+	{"controller/demo.go", "resource"},
+	{"logic", "resource"},
+	{"models", "resource"},
+}
+
+// skipDirs 不复制的目录
+var skipDirs = map[string]bool{".git": true, ".idea": true, "log": true}
+
+var (
+	resourcePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	markerPattern   = regexp.MustCompile(`^(?://|#|--)\s*gen:(if\s+(\w+)|end)\s*$`)
+	portPattern     = regexp.MustCompile(`(?m)^port:\s*\d+\s*$`)
+	namePattern     = regexp.MustCompile(`(?m)^name:.*$`)
+	replacePattern  = regexp.MustCompile(`(?m)^replace kit => (\S+)\s*$`)
+	// headerPattern 模板文件开头的IDE文件头，新项目不需要
+	headerPattern = regexp.MustCompile(`^/\*\*\s*\n\s*@Go version(?s:.*?)\*/\n`)
+)
+
+func projectCommand(args []string) int {
+	opts := projectOptions{Components: map[string]bool{}}
+	flags := flag.NewFlagSet("project", flag.ContinueOnError)
+	flags.StringVar(&opts.Template, "template", "../base/projectTemplate", "模板项目目录")
+	flags.StringVar(&opts.Out, "out", "", "输出目录（必填）")
+	flags.StringVar(&opts.Module, "module", "", "新项目的模块名（必填），如 example.com/blog")
+	flags.IntVar(&opts.Port, "port", 8080, "服务端口")
+	flags.StringVar(&opts.Resource, "resource", "", "示例CRUD资源名，小写加下划线，如 article；为空时不生成")
+	mysql := flags.Bool("mysql", true, "MySQL")
+	redis := flags.Bool("redis", true, "Redis")
+	snowflake := flags.Bool("snowflake", true, "雪花算法生成ID")
+	validator := flags.Bool("validator", true, "参数校验错误翻译成中文")
+	flags.BoolVar(&opts.Force, "force", false, "输出目录不为空时也写入，会覆盖同名文件")
+	flags.BoolVar(&opts.Tidy, "tidy", true, "生成后执行 go mod tidy，去掉没有用到的依赖")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	opts.Components["mysql"] = *mysql
+	opts.Components["redis"] = *redis
+	opts.Components["snowflake"] = *snowflake
+	opts.Components["validator"] = *validator
+	opts.Components["resource"] = opts.Resource != ""
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		flags.Usage()
+		return 2
+	}
+	if err := renderProject(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "generate project failed: %v\n", err)
+		return 1
+	}
+	if opts.Tidy {
+		cmd := exec.Command("go", "mod", "tidy")
+		cmd.Dir, cmd.Stdout, cmd.Stderr = opts.Out, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "go mod tidy failed, run it manually in %s: %v\n", opts.Out, err)
+		}
+	}
+	fmt.Printf("project %s generated in %s\n", opts.Module, opts.Out)
+	return 0
+}
+
+func (o *projectOptions) validate() error {
+	switch {
+	case o.Out == "":
+		return errors.New("-out is required")
+	case o.Module == "" || strings.ContainsAny(o.Module, " \t\"\\"):
+		return fmt.Errorf("invalid -module %q", o.Module)
+	case o.Port < 1 || o.Port > 65535:
+		return fmt.Errorf("-port must be between 1 and 65535, got %d", o.Port)
+	case o.Resource != "" && !resourcePattern.MatchString(o.Resource):
+		return fmt.Errorf("invalid -resource %q, use lower case letters, digits and underscores", o.Resource)
+	case o.Resource != "" && !o.Components["mysql"]:
+		return errors.New("-resource needs mysql, remove -mysql=false")
+	}
+	if !o.Force {
+		entries, err := os.ReadDir(o.Out)
+		if err == nil && len(entries) > 0 {
+			return fmt.Errorf("%s is not empty, use -force to overwrite", o.Out)
+		}
+	}
+	return nil
+}
+
+// renderProject 遍历模板目录，跳过没有选择的组件，替换模块名和资源名后写到输出目录
+func renderProject(o projectOptions) error {
+	kitDir, err := templateKitDir(o.Template)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(o.Template, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(o.Template, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if skipDirs[d.Name()] || !o.included(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !o.included(rel) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if content, err = o.render(rel, content, kitDir); err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		dst := filepath.Join(o.Out, filepath.FromSlash(o.rename(rel)))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(dst, content, 0o644)
+	})
+}
+
+// included 文件所属的组件是否选择了
+func (o *projectOptions) included(rel string) bool {
+	for _, f := range componentFiles {
+		if rel == f.path || strings.HasPrefix(rel, f.path+"/") {
+			return o.Components[f.component]
+		}
+	}
+	return true
+}
+
+func (o *projectOptions) render(rel string, content []byte, kitDir string) ([]byte, error) {
+	s, err := o.strip(string(content))
+	if err != nil {
+		return nil, err
+	}
+	// 去掉组件后可能留下连续的空行
+	for strings.Contains(s, "\n\n\n") {
+		s = strings.ReplaceAll(s, "\n\n\n", "\n\n")
+	}
+	if o.Resource != "" {
+		// 先替换复数形式，ListDemos -> ListCategories
+		s = strings.ReplaceAll(s, camelCase(templateResource)+"s", plural(camelCase(o.Resource)))
+		s = strings.ReplaceAll(s, camelCase(templateResource), camelCase(o.Resource))
+		s = strings.ReplaceAll(s, templateResource, o.Resource)
+	}
+	switch {
+	case rel == "go.mod":
+		s = strings.Replace(s, "module "+templateModule, "module "+o.Module, 1)
+		s = replacePattern.ReplaceAllString(s, "replace kit => "+o.kitPath(kitDir))
+	case strings.HasSuffix(rel, ".go"):
+		s = strings.ReplaceAll(s, `"`+templateModule+`/`, `"`+o.Module+`/`)
+		s = headerPattern.ReplaceAllString(s, "")
+		out, err := format.Source([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	case rel == "config.yaml":
+		s = namePattern.ReplaceAllString(s, fmt.Sprintf("name: %q", path.Base(o.Module)))
+		s = portPattern.ReplaceAllString(s, fmt.Sprintf("port: %d", o.Port))
+		s = strings.ReplaceAll(s, templateModule+".log", path.Base(o.Module)+".log")
+	}
+	return []byte(s), nil
+}
+
+// rename 文件名中的示例资源名
+func (o *projectOptions) rename(rel string) string {
+	if o.Resource == "" {
+		return rel
+	}
+	dir, file := path.Split(rel)
+	return dir + strings.ReplaceAll(file, templateResource, o.Resource)
+}
+
+// strip 按 gen:if 和 gen:end 标记去掉没有选择的组件的代码，标记行本身也去掉，支持嵌套
+func (o *projectOptions) strip(s string) (string, error) {
+	var (
+		b     strings.Builder
+		stack []bool // 每一层是否保留
+		line  int
+	)
+	keep := func() bool {
+		for _, k := range stack {
+			if !k {
+				return false
+			}
+		}
+		return true
+	}
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if m := markerPattern.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+			if m[1] == "end" {
+				if len(stack) == 0 {
+					return "", fmt.Errorf("line %d: gen:end without gen:if", line)
+				}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			enabled, ok := o.Components[m[2]]
+			if !ok {
+				return "", fmt.Errorf("line %d: unknown component %q", line, m[2])
+			}
+			stack = append(stack, enabled)
+			continue
+		}
+		if keep() {
+			b.WriteString(text)
+			b.WriteByte('\n')
+		}
+	}
+	if len(stack) > 0 {
+		return "", errors.New("gen:if without gen:end")
+	}
+	return b.String(), scanner.Err()
+}
+
+// templateKitDir 模板 go.mod 中 replace 指向的kit目录
+func templateKitDir(template string) (string, error) {
+	gomod, err := os.ReadFile(filepath.Join(template, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("read template go.mod failed: %w", err)
+	}
+	m := replacePattern.FindSubmatch(gomod)
+	if m == nil {
+		return "", errors.New("no replace directive for kit in template go.mod")
+	}
+	return filepath.Abs(filepath.Join(template, string(m[1])))
+}
+
+// kitPath 新项目 go.mod 中指向kit的路径，能算出相对路径时使用相对路径
+func (o *projectOptions) kitPath(kitDir string) string {
+	out, err := filepath.Abs(o.Out)
+	if err != nil {
+		return kitDir
+	}
+	rel, err := filepath.Rel(out, kitDir)
+	if err != nil {
+		return kitDir
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, ".") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// camelCase blog_post -> BlogPost
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// plural 英文复数，只处理常见的规则
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
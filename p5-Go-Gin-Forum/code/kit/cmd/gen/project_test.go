@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestProjectBuilds 默认参数和主要的组件组合生成的项目都要能编译
+func TestProjectBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated projects")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	all := map[string]bool{"mysql": true, "redis": true, "snowflake": true, "validator": true}
+	without := func(names ...string) map[string]bool {
+		c := map[string]bool{}
+		for k, v := range all {
+			c[k] = v
+		}
+		for _, n := range names {
+			c[n] = false
+		}
+		return c
+	}
+	tests := []struct {
+		name       string
+		resource   string
+		components map[string]bool
+	}{
+		{"default", "", all},
+		{"resource", "article", all},
+		{"no redis", "article", without("redis")},
+		{"no snowflake", "article", without("snowflake")},
+		{"no validator", "article", without("validator")},
+		{"mysql only", "", without("redis", "snowflake", "validator")},
+		{"nothing", "", without("mysql", "redis", "snowflake", "validator")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := projectOptions{
+				Template:   "../../../base/projectTemplate",
+				Out:        filepath.Join(t.TempDir(), "blog"),
+				Module:     "example.com/blog",
+				Port:       8080,
+				Resource:   tt.resource,
+				Components: map[string]bool{"resource": tt.resource != ""},
+			}
+			for k, v := range tt.components {
+				opts.Components[k] = v
+			}
+			if err := opts.validate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := renderProject(opts); err != nil {
+				t.Fatalf("renderProject: %v", err)
+			}
+			// 没有 -resource 时不能留下引用 models 的示例DAO
+			if _, err := os.Stat(filepath.Join(opts.Out, "dao", "mysql", "demo.go")); err == nil {
+				t.Error("dao/mysql/demo.go should not be generated")
+			}
+
+			cmd := exec.Command("go", "build", "./...")
+			cmd.Dir = opts.Out
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("go build failed: %v\n%s", err, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+
+-- 不在 create_table.sql 中的表：字符串主键、自增主键以外的类型
+DROP TABLE IF EXISTS `tag`;
+CREATE TABLE `tag` (
+    `tag_name` varchar(32) NOT NULL COMMENT '标签名',
+    `kind` enum('topic','label') NOT NULL DEFAULT 'topic',
+    `weight` decimal(10,2) DEFAULT NULL,
+    `hidden` tinyint(1) NOT NULL DEFAULT '0',
+    `meta` json,
+    `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`tag_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='标签';
@@ -0,0 +1,5 @@
+package controller
+
+import "github.com/gin-gonic/gin"
+
+func invalidParam(c *gin.Context, err error) {}
@@ -0,0 +1,20 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Querier interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func DB() Querier { return nil }
+
+func WithPrimary(ctx context.Context) context.Context { return ctx }
+
+var _ *sqlx.DB
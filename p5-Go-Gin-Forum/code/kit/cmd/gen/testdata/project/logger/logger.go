@@ -0,0 +1,9 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+func WithContext(ctx context.Context) *zap.Logger { return zap.L() }
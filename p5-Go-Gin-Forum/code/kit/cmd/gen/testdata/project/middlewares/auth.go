@@ -0,0 +1,5 @@
+package middlewares
+
+import "github.com/gin-gonic/gin"
+
+func AdminAuth() gin.HandlerFunc { return nil }
@@ -0,0 +1,3 @@
+package snowflake
+
+func GetID() (uint64, error) { return 0, nil }
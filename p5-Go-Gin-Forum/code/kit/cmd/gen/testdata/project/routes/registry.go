@@ -0,0 +1,5 @@
+package routes
+
+import "github.com/gin-gonic/gin"
+
+func register(fn func(r *gin.Engine)) {}
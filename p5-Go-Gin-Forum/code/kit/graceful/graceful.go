@@ -0,0 +1,145 @@
+// graceful 通过传递监听socket实现不停机重启
+//
+// 旧进程收到SIGHUP后，把监听socket和一个用于通知就绪的管道作为ExtraFiles传给新进程，
+// 新进程复用这个socket开始服务后写管道通知旧进程，旧进程再优雅关闭。
+// 整个过程中socket一直处于监听状态，客户端的连接不会被拒绝。
+package graceful
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// EnvPrefix 告诉子进程继承来的文件描述符编号的环境变量前缀，如 FORUM 时为 FORUM_LISTEN_FD、FORUM_READY_FD
+// 新旧版本之间重启时两边的前缀必须一致
+var EnvPrefix = "KIT"
+
+func envListenFD() string { return EnvPrefix + "_LISTEN_FD" }
+func envReadyFD() string  { return EnvPrefix + "_READY_FD" }
+
+// ExtraFiles 中的文件在子进程里从3开始编号（0、1、2是标准输入输出）
+const (
+	listenFD = 3
+	readyFD  = 4
+)
+
+// Inherited 当前进程是否是由旧进程重启出来的
+func Inherited() bool {
+	return os.Getenv(envListenFD()) != ""
+}
+
+// Listen 如果继承了父进程的监听socket就直接复用，否则新建监听
+func Listen(addr string) (net.Listener, error) {
+	v := os.Getenv(envListenFD())
+	if v == "" {
+		return net.Listen("tcp", addr)
+	}
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s=%q", envListenFD(), v)
+	}
+	f := os.NewFile(uintptr(fd), "listener")
+	defer f.Close() // FileListener会dup一份，这里的可以关掉
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherit listener failed: %w", err)
+	}
+	return ln, nil
+}
+
+// Ready 通知父进程新进程已经可以接收请求，不是重启出来的进程调用时什么也不做
+func Ready() error {
+	v := os.Getenv(envReadyFD())
+	if v == "" {
+		return nil
+	}
+	// 之后再fork出来的进程不应该看到这两个变量
+	_ = os.Unsetenv(envListenFD())
+	_ = os.Unsetenv(envReadyFD())
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s=%q", envReadyFD(), v)
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
+
+// Restart 用相同的命令行启动新进程并把监听socket交给它，
+// 在timeout内等到新进程就绪才返回nil，此时调用方就可以关闭自己的服务了
+func Restart(ln net.Listener, timeout time.Duration) (pid int, err error) {
+	tl, ok := ln.(*net.TCPListener)
+	if !ok {
+		return 0, errors.New("only tcp listener can be handed off")
+	}
+	lnFile, err := tl.File()
+	if err != nil {
+		return 0, err
+	}
+	defer lnFile.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer readyR.Close()
+
+	bin, err := os.Executable()
+	if err != nil {
+		_ = readyW.Close()
+		return 0, err
+	}
+	cmd := exec.Command(bin, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{lnFile, readyW}
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%d", envListenFD(), listenFD),
+		fmt.Sprintf("%s=%d", envReadyFD(), readyFD),
+	)
+	err = cmd.Start()
+	// 子进程已经持有写端，父进程必须关掉自己的，否则子进程退出时读不到EOF
+	_ = readyW.Close()
+	if err != nil {
+		return 0, err
+	}
+	// 回收子进程，避免新进程先于旧进程退出时变成僵尸进程
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	if err = waitReady(readyR, timeout, exited); err != nil {
+		_ = cmd.Process.Kill()
+		return 0, err
+	}
+	return cmd.Process.Pid, nil
+}
+
+func waitReady(r *os.File, timeout time.Duration, exited <-chan error) error {
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := r.Read(buf)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("new process exited before ready: %w", err)
+		}
+		return nil
+	case err := <-exited:
+		return fmt.Errorf("new process exited before ready: %v", err)
+	case <-time.After(timeout):
+		return fmt.Errorf("new process not ready after %s", timeout)
+	}
+}
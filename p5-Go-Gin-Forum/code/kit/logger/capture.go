@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// limitedBuffer 最多保存 limit 字节，超出的部分丢弃并标记为截断
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// teeReadCloser 读请求体的同时保存一份，不影响后面的handler读取
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyWriter 写响应的同时保存一份
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *limitedBuffer
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	_, _ = w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	_, _ = w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// bodyCapture 一个请求的记录
+type bodyCapture struct {
+	req, resp *limitedBuffer
+}
+
+// startCapture 包装请求体和响应，只保存handler实际读取和写出的部分
+func startCapture(c *gin.Context, maxBody int) *bodyCapture {
+	bc := &bodyCapture{
+		req:  &limitedBuffer{limit: maxBody},
+		resp: &limitedBuffer{limit: maxBody},
+	}
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		c.Request.Body = teeReadCloser{io.TeeReader(c.Request.Body, bc.req), c.Request.Body}
+	}
+	c.Writer = &bodyWriter{ResponseWriter: c.Writer, body: bc.resp}
+	return bc
+}
+
+// fields 打码后的请求体和响应体
+func (bc *bodyCapture) fields(c *gin.Context, r *Redactor) []zap.Field {
+	return []zap.Field{
+		zap.String("request_body", r.Body(c.ContentType(), bc.req.buf.Bytes(), bc.req.truncated)),
+		zap.String("response_body", r.Body(c.Writer.Header().Get("Content-Type"), bc.resp.buf.Bytes(), bc.resp.truncated)),
+		zap.Bool("body_truncated", bc.req.truncated || bc.resp.truncated),
+	}
+}
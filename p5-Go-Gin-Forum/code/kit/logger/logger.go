@@ -0,0 +1,195 @@
+// logger 基于zap的日志初始化：JSON格式写入lumberjack切分的文件，开发模式同时输出到终端
+package logger
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Options 日志参数
+type Options struct {
+	Level      string // debug、info、warn、error ...
+	Filename   string
+	MaxSize    int  // 单个文件的大小上限（MB）
+	MaxAge     int  // 旧文件保留的天数
+	MaxBackups int  // 旧文件保留的个数
+	Console    bool // 同时输出到终端，开发模式使用
+}
+
+// New 创建logger，返回的 AtomicLevel 可以在运行时修改级别
+func New(opts Options) (*zap.Logger, zap.AtomicLevel, error) {
+	atom := zap.NewAtomicLevel()
+	if err := atom.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, atom, err
+	}
+	core := FileCore(opts, atom)
+	if opts.Console {
+		core = zapcore.NewTee(core, ConsoleCore())
+	}
+	return zap.New(core, zap.AddCaller()), atom, nil
+}
+
+// Init 创建logger并替换zap的全局logger，之后用 zap.L() 记录日志
+func Init(opts Options) (zap.AtomicLevel, error) {
+	lg, atom, err := New(opts)
+	if err != nil {
+		return atom, err
+	}
+	zap.ReplaceGlobals(lg)
+	return atom, nil
+}
+
+// FileCore 写文件的core，需要自己组合core的项目使用
+func FileCore(opts Options, enab zapcore.LevelEnabler) zapcore.Core {
+	return zapcore.NewCore(Encoder(), Writer(opts.Filename, opts.MaxSize, opts.MaxBackups, opts.MaxAge), enab)
+}
+
+// ConsoleCore 输出到终端的core，不过滤级别
+func ConsoleCore() zapcore.Core {
+	consoleEncoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	return zapcore.NewCore(consoleEncoder, zapcore.Lock(os.Stdout), zapcore.DebugLevel)
+}
+
+// Encoder 文件日志的JSON编码
+func Encoder() zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.TimeKey = "time"
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	encoderConfig.EncodeDuration = zapcore.SecondsDurationEncoder
+	encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+	return zapcore.NewJSONEncoder(encoderConfig)
+}
+
+// Writer 按大小切分的日志文件
+func Writer(filename string, maxSize, maxBackup, maxAge int) zapcore.WriteSyncer {
+	lumberJackLogger := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackup,
+		MaxAge:     maxAge,
+	}
+	return zapcore.AddSync(lumberJackLogger)
+}
+
+// IsBrokenPipe panic是否是客户端断开连接引起的，这种情况不需要记录堆栈，也无法再写响应
+func IsBrokenPipe(err interface{}) bool {
+	ne, ok := err.(*net.OpError)
+	if !ok {
+		return false
+	}
+	se, ok := ne.Err.(*os.SyscallError)
+	if !ok {
+		return false
+	}
+	msg := strings.ToLower(se.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
+
+// GinOptions GinLogger 和 GinRecovery 的可选参数，零值时按内置列表打码，不记录请求体
+// 都是函数，每个请求调用一次，配置热加载后不用重建中间件
+type GinOptions struct {
+	Redactor func() *Redactor                      // 打码规则，返回nil时使用内置列表
+	Access   func() *zap.Logger                    // 正常请求的访问日志使用的logger，如包了sampler的，返回nil时用lg；出错的请求和记录了请求体的请求仍用lg
+	Capture  func(c *gin.Context) int              // 记录请求体和响应体时返回保存的最大字节数，0表示不记录
+	Fields   func(ctx context.Context) []zap.Field // 每条日志附加的字段，如 trace_id
+}
+
+func (o *GinOptions) redactor() *Redactor {
+	if o.Redactor != nil {
+		if r := o.Redactor(); r != nil {
+			return r
+		}
+	}
+	return defaultRedactor
+}
+
+func (o *GinOptions) with(lg *zap.Logger, ctx context.Context) *zap.Logger {
+	if o.Fields == nil {
+		return lg
+	}
+	return lg.With(o.Fields(ctx)...)
+}
+
+// GinLogger 用zap记录gin的访问日志，查询参数和请求体打码后再记录
+func GinLogger(lg *zap.Logger, opts GinOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+		var bc *bodyCapture
+		if opts.Capture != nil {
+			if maxBody := opts.Capture(c); maxBody > 0 {
+				bc = startCapture(c, maxBody)
+			}
+		}
+		c.Next()
+
+		r := opts.redactor()
+		fields := []zap.Field{
+			zap.Int("status", c.Writer.Status()),
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("query", r.Query(query)),
+			zap.String("ip", c.ClientIP()),
+			zap.String("user-agent", c.Request.UserAgent()),
+			zap.String("errors", c.Errors.ByType(gin.ErrorTypePrivate).String()),
+			zap.Duration("cost", time.Since(start)),
+		}
+		// 出错的请求和记录了请求体的请求不参与采样
+		l := lg
+		if opts.Access != nil && c.Writer.Status() < http.StatusBadRequest && len(c.Errors) == 0 && bc == nil {
+			if access := opts.Access(); access != nil {
+				l = access
+			}
+		}
+		if bc != nil {
+			fields = append(fields, bc.fields(c, r)...)
+		}
+		opts.with(l, c.Request.Context()).Info(path, fields...)
+	}
+}
+
+// GinRecovery recover掉handler的panic并用zap记录，stack为true时记录堆栈
+// 请求头和查询参数打码后再记录，不记录请求体
+func GinRecovery(lg *zap.Logger, stack bool, opts GinOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// 代替 httputil.DumpRequest，后者会把 Authorization、Cookie 原样写进日志
+			httpRequest := opts.redactor().Request(c.Request)
+			lg := opts.with(lg, c.Request.Context())
+			if IsBrokenPipe(err) {
+				lg.Error(c.Request.URL.Path,
+					zap.Any("error", err),
+					zap.String("request", httpRequest),
+				)
+				// If the connection is dead, we can't write a status to it.
+				c.Error(err.(error)) // nolint: errcheck
+				c.Abort()
+				return
+			}
+
+			fields := []zap.Field{zap.Any("error", err), zap.String("request", httpRequest)}
+			if stack {
+				fields = append(fields, zap.String("stack", string(debug.Stack())))
+			}
+			lg.Error("[Recovery from panic]", fields...)
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
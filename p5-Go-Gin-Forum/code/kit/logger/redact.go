@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue 打码后的值
+const RedactedValue = "******"
+
+// 内置的打码列表，只能追加不能去掉
+var (
+	defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Admin-Token"}
+	defaultRedactFields  = []string{"password", "re_password", "token", "access_token", "refresh_token", "secret"}
+)
+
+// Redactor 日志中请求头、查询参数和请求体的打码规则，名字都转成小写，创建后不再修改
+type Redactor struct {
+	headers map[string]bool
+	fields  map[string]bool
+}
+
+// defaultRedactor 只有内置列表的规则，GinOptions 没有指定 Redactor 时使用
+var defaultRedactor = NewRedactor(nil, nil)
+
+// NewRedactor 在内置列表上追加需要打码的请求头和字段名（JSON字段、表单和查询参数）
+func NewRedactor(headers, fields []string) *Redactor {
+	r := &Redactor{headers: map[string]bool{}, fields: map[string]bool{}}
+	for _, h := range append(append([]string{}, defaultRedactHeaders...), headers...) {
+		r.headers[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	for _, f := range append(append([]string{}, defaultRedactFields...), fields...) {
+		r.fields[strings.ToLower(strings.TrimSpace(f))] = true
+	}
+	return r
+}
+
+// Header 返回打码后的请求头副本，不修改原请求
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		if r.headers[strings.ToLower(k)] {
+			out[k] = []string{RedactedValue}
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// Query 对查询字符串中的敏感参数打码，如 ?token=xx，其他参数保持原样和原来的顺序
+func (r *Redactor) Query(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	pairs := strings.Split(raw, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			// 解析不了就整个不记录，避免漏掉
+			return RedactedValue
+		}
+		if r.fields[strings.ToLower(name)] {
+			pairs[i] = key + "=" + RedactedValue
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// Form 对 application/x-www-form-urlencoded 的请求体打码
+func (r *Redactor) Form(body []byte) []byte {
+	return []byte(r.Query(string(body)))
+}
+
+// JSON 对JSON中任意层级的敏感字段打码；不是合法的JSON时整个不记录
+func (r *Redactor) JSON(body []byte) []byte {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // 保持大整数（如雪花ID）的原样
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []byte(RedactedValue)
+	}
+	if !r.walk(v) {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return []byte(RedactedValue)
+	}
+	return out
+}
+
+// walk 递归打码，返回是否有字段被打码
+func (r *Redactor) walk(v interface{}) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if r.fields[strings.ToLower(k)] {
+				t[k] = RedactedValue
+				changed = true
+				continue
+			}
+			if r.walk(child) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range t {
+			if r.walk(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Request 转成日志中的文本：请求行和打码后的请求头，不包含请求体
+// 代替 httputil.DumpRequest，后者会把 Authorization、Cookie 原样写进日志
+func (r *Redactor) Request(req *http.Request) string {
+	var b strings.Builder
+	uri := req.URL.Path
+	if q := r.Query(req.URL.RawQuery); q != "" {
+		uri += "?" + q
+	}
+	b.WriteString(req.Method + " " + uri + " " + req.Proto + "\r\n")
+	b.WriteString("Host: " + req.Host + "\r\n")
+	_ = r.Header(req.Header).Write(&b)
+	return b.String()
+}
+
+// Body 按内容类型打码，只有JSON和表单能按字段名打码，其他类型（包括text和xml）只记录类型
+// 被截断的JSON无法解析，为了不漏掉敏感字段整个不记录
+func (r *Redactor) Body(contentType string, body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if truncated {
+			return RedactedValue
+		}
+		return string(r.JSON(body))
+	case mediaType == "application/x-www-form-urlencoded":
+		return string(r.Form(body))
+	default:
+		return "[" + mediaTypeOrUnknown(mediaType) + " body omitted]"
+	}
+}
+
+func mediaTypeOrUnknown(mediaType string) string {
+	if mediaType == "" {
+		return "unknown"
+	}
+	return mediaType
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestDefaultRedaction 零值的 GinOptions 也要按内置列表打码，gen project 生成的项目就是这样用的
+func TestDefaultRedaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.DebugLevel)
+	lg := zap.New(core)
+
+	r := gin.New()
+	r.Use(GinLogger(lg, GinOptions{}), GinRecovery(lg, true, GinOptions{}))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	for _, target := range []string{"/ok?page=1&token=query-token", "/panic?access_token=panic-token"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer auth-secret")
+		req.Header.Set("Cookie", "session=cookie-secret")
+		req.Header.Set("X-Admin-Token", "admin-secret")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var out strings.Builder
+	for _, e := range logs.All() {
+		out.WriteString(e.Message + "\n")
+		for k, v := range e.ContextMap() {
+			out.WriteString(k + "=" + fmt.Sprint(v) + "\n")
+		}
+	}
+	for _, secret := range []string{"query-token", "panic-token", "auth-secret", "cookie-secret", "admin-secret"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("secret %q found in log:\n%s", secret, out.String())
+		}
+	}
+	for _, want := range []string{"page=1", "[Recovery from panic]", RedactedValue} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("log should contain %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// mysql 建立sqlx连接池
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// Options 连接参数
+type Options struct {
+	Host         string
+	Port         int
+	User         string
+	Password     string
+	DBName       string
+	MaxOpenConns int
+	MaxIdleConns int
+	// Open 打开连接池，为空时使用 sql.Open，需要包装驱动（如otelsql）时设置
+	Open func(driverName, dsn string) (*sql.DB, error)
+}
+
+// DSN 连接串，固定使用utf8mb4并把时间解析为 time.Time
+func DSN(opts Options) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True",
+		opts.User,
+		opts.Password,
+		opts.Host,
+		opts.Port,
+		opts.DBName,
+	)
+}
+
+// Open 打开连接池并ping一次，连不上时返回错误
+func Open(opts Options) (*sqlx.DB, error) {
+	open := opts.Open
+	if open == nil {
+		open = sql.Open
+	}
+	sqlDB, err := open("mysql", DSN(opts))
+	if err != nil {
+		return nil, err
+	}
+	db := sqlx.NewDb(sqlDB, "mysql")
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	return db, nil
+}
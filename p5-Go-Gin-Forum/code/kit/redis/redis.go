@@ -0,0 +1,135 @@
+// redis 按部署模式创建go-redis客户端：单节点、哨兵或集群
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const pingTimeout = 5 * time.Second
+
+// Options 连接参数
+type Options struct {
+	Mode       string   // single（默认）、sentinel、cluster
+	Host       string   // single 模式使用
+	Port       int      // single 模式使用
+	Addrs      []string // sentinel 模式是哨兵地址，cluster 模式是种子节点
+	MasterName string   // sentinel 模式使用
+	Password   string
+	DB         int // cluster 模式不支持选库
+	PoolSize   int
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+
+	TLS   *TLSOptions  // 为空时不使用TLS
+	Hooks []redis.Hook // 创建后依次添加，如超时、链路追踪
+}
+
+// TLSOptions TLS参数
+type TLSOptions struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// New 创建客户端并ping一次，连不上时关闭客户端并返回错误
+// 不同模式下分别是 *redis.Client（单节点、哨兵）或 *redis.ClusterClient，调用方只看到 UniversalClient
+func New(opts Options) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var c redis.UniversalClient
+	// 按mode显式创建，NewUniversalClient 会把只有一个种子节点的集群当成单节点
+	switch opts.Mode {
+	case "sentinel":
+		c = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    opts.MasterName,
+			SentinelAddrs: opts.Addrs,
+			Password:      opts.Password,
+			DB:            opts.DB,
+			PoolSize:      opts.PoolSize,
+			DialTimeout:   opts.DialTimeout,
+			ReadTimeout:   opts.ReadTimeout,
+			WriteTimeout:  opts.WriteTimeout,
+			PoolTimeout:   opts.PoolTimeout,
+			TLSConfig:     tlsConfig,
+		})
+	case "cluster":
+		c = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        opts.Addrs,
+			Password:     opts.Password,
+			PoolSize:     opts.PoolSize,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+			PoolTimeout:  opts.PoolTimeout,
+			TLSConfig:    tlsConfig,
+		})
+	default:
+		c = redis.NewClient(&redis.Options{
+			Addr:         fmt.Sprintf("%s:%d", opts.Host, opts.Port),
+			Password:     opts.Password,
+			DB:           opts.DB,
+			PoolSize:     opts.PoolSize,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+			PoolTimeout:  opts.PoolTimeout,
+			TLSConfig:    tlsConfig,
+		})
+	}
+	for _, h := range opts.Hooks {
+		c.AddHook(h)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if _, err := c.Ping(ctx).Result(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newTLSConfig 未开启TLS时返回nil
+func newTLSConfig(opts *TLSOptions) (*tls.Config, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	tc := &tls.Config{
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read redis ca file failed: %w", err)
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in redis ca file %s", opts.CAFile)
+		}
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load redis client certificate failed: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
@@ -0,0 +1,134 @@
+// server 启动HTTP服务并在收到信号后优雅关闭
+// SIGINT、SIGTERM：等正在处理的请求完成后退出；开启 Graceful 时 SIGHUP 会不停机重启（见 kit/graceful）
+package server
+
+import (
+	"context"
+	"fmt"
+	"kit/graceful"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 默认值
+const (
+	defaultWaitTime     = 20 * time.Second
+	defaultReadyTimeout = 30 * time.Second
+)
+
+// Options 服务参数
+type Options struct {
+	Addr string
+	// WaitTime 关闭时最多等待多久，收到信号时才调用，配置热加载后的新值也能生效；为空时等待20秒
+	WaitTime func() time.Duration
+	// Graceful 开启后SIGHUP触发不停机重启，新进程就绪后当前进程再退出
+	Graceful bool
+	// ReadyTimeout SIGHUP重启时等待新进程就绪的最长时间，默认30秒
+	ReadyTimeout time.Duration
+	// OnShutdown HTTP服务关闭后按顺序调用，和关闭服务共用同一个超时，用于停止后台任务等；关闭超时也会调用
+	OnShutdown []func(ctx context.Context)
+}
+
+// Run 启动服务并阻塞到服务关闭，监听失败或等待超时时返回错误，返回前 OnShutdown 已经执行完
+func Run(handler http.Handler, opts Options) error {
+	srv := &http.Server{
+		Addr:    opts.Addr,
+		Handler: handler,
+	}
+	var (
+		ln  net.Listener
+		err error
+	)
+	if opts.Graceful {
+		// 如果是SIGHUP重启出来的新进程，这里拿到的是旧进程交过来的socket
+		ln, err = graceful.Listen(srv.Addr)
+	} else {
+		ln, err = net.Listen("tcp", srv.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("listen failed: %w", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		// 开启一个goroutine启动服务
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+	if opts.Graceful {
+		// 通知旧进程（如果有）可以开始退出了
+		if err := graceful.Ready(); err != nil {
+			zap.L().Error("notify parent ready failed", zap.Error(err))
+		}
+	}
+
+	if err := waitSignal(ln, opts, serveErr); err != nil {
+		return err
+	}
+
+	waitTime := defaultWaitTime
+	if opts.WaitTime != nil {
+		waitTime = opts.WaitTime()
+	}
+	zap.L().Info(fmt.Sprintf("触发关闭等待服务，将等待%ds", int(waitTime.Seconds())))
+	// 创建一个wait_time超时的context
+	ctx, cancel := context.WithTimeout(context.Background(), waitTime)
+	defer cancel()
+	// wait_time内优雅关闭服务（将未处理完的请求处理完再关闭服务），超时就退出
+	shutdownErr := srv.Shutdown(ctx)
+	// 超时了也要调用，释放锁、停止后台任务，不能等锁过期
+	for _, fn := range opts.OnShutdown {
+		fn(ctx)
+	}
+	if shutdownErr != nil {
+		return fmt.Errorf("等了%ds了还没好，先撤了...: %w", int(waitTime.Seconds()), shutdownErr)
+	}
+	zap.L().Info("所有请求处理完成,服务正常退出")
+	return nil
+}
+
+// waitSignal 阻塞到收到退出信号或重启成功
+func waitSignal(ln net.Listener, opts Options, serveErr <-chan error) error {
+	quit := make(chan os.Signal, 1) // 创建一个接收信号的通道
+	// kill 默认会发送 syscall.SIGTERM 信号
+	// kill -1 发送 syscall.SIGHUP 信号，开启 Graceful 时触发不停机重启
+	// kill -2 发送 syscall.SIGINT 信号，我们常用的Ctrl+C就是触发系统SIGINT信号
+	// kill -9 发送 syscall.SIGKILL 信号，但是不能被捕获，所以不需要添加它
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	if opts.Graceful {
+		signals = append(signals, syscall.SIGHUP)
+	}
+	signal.Notify(quit, signals...) // 此处不会阻塞
+	defer signal.Stop(quit)
+
+	readyTimeout := opts.ReadyTimeout
+	if readyTimeout <= 0 {
+		readyTimeout = defaultReadyTimeout
+	}
+	for {
+		var sig os.Signal
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("serve failed: %w", err)
+		case sig = <-quit: // 阻塞在此，收到信号才会往下执行
+		}
+		if sig != syscall.SIGHUP {
+			return nil
+		}
+		// 新进程就绪后旧进程才退出；启动失败就继续由当前进程服务
+		pid, err := graceful.Restart(ln, readyTimeout)
+		if err != nil {
+			zap.L().Error("graceful restart failed, keep serving", zap.Error(err))
+			continue
+		}
+		zap.L().Info("new process is ready, draining old process", zap.Int("pid", pid))
+		return nil
+	}
+}
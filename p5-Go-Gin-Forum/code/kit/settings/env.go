@@ -0,0 +1,127 @@
+package settings
+
+import (
+	"os"
+	"reflect"
+	"strings"
+)
+
+const redactedValue = "******"
+
+// bindEnvs 让配置结构体的每个字段都可以被环境变量覆盖
+// 只开 AutomaticEnv 的话，配置文件里没写的key在Unmarshal时读不到环境变量，所以要逐个BindEnv
+func (l *Loader[T]) bindEnvs() error {
+	if l.opts.EnvPrefix == "" {
+		return nil
+	}
+	l.v.SetEnvPrefix(l.opts.EnvPrefix)
+	l.v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	l.v.AutomaticEnv()
+
+	var err error
+	walkFields(reflect.TypeOf(new(T)).Elem(), "", func(key string, _ reflect.StructField) {
+		if err == nil {
+			err = l.v.BindEnv(key)
+		}
+	})
+	return err
+}
+
+// applySecretFiles 从 *_FILE 指向的文件读取配置值（Docker/Kubernetes secrets）
+// 例如 FORUM_MYSQL_PASSWORD_FILE=/run/secrets/mysql_password；同名的环境变量优先级更高
+func (l *Loader[T]) applySecretFiles() error {
+	if l.opts.EnvPrefix == "" {
+		return nil
+	}
+	var err error
+	walkFields(reflect.TypeOf(new(T)).Elem(), "", func(key string, _ reflect.StructField) {
+		if err != nil {
+			return
+		}
+		name := l.envName(key)
+		if _, ok := os.LookupEnv(name); ok {
+			return
+		}
+		path, ok := os.LookupEnv(name + "_FILE")
+		if !ok {
+			return
+		}
+		var b []byte
+		if b, err = os.ReadFile(path); err != nil {
+			return
+		}
+		// secrets 文件末尾通常带换行
+		l.v.Set(key, strings.TrimRight(string(b), "\r\n"))
+	})
+	return err
+}
+
+func (l *Loader[T]) envName(key string) string {
+	return l.opts.EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Redacted 返回用于打印的配置，带 secret:"true" 标签的字段会被打码，cfg 为指向结构体的指针
+func Redacted(cfg interface{}) map[string]interface{} {
+	return redact(reflect.ValueOf(cfg).Elem())
+}
+
+func redact(v reflect.Value) map[string]interface{} {
+	out := make(map[string]interface{})
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := tagName(f)
+		if name == "" {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				out[name] = nil
+				continue
+			}
+			fv = fv.Elem()
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			out[name] = redact(fv)
+		case f.Tag.Get("secret") == "true" && !fv.IsZero():
+			out[name] = redactedValue
+		default:
+			out[name] = fv.Interface()
+		}
+	}
+	return out
+}
+
+// walkFields 按 mapstructure 标签遍历配置结构体的叶子字段，key形如 mysql.password
+func walkFields(t reflect.Type, prefix string, fn func(key string, f reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := tagName(f)
+		if name == "" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			walkFields(ft, key, fn)
+			continue
+		}
+		fn(key, f)
+	}
+}
+
+func tagName(f reflect.StructField) string {
+	name := strings.SplitN(f.Tag.Get("mapstructure"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
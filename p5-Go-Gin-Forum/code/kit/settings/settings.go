@@ -0,0 +1,85 @@
+// settings 把配置文件、环境变量和secrets文件合并成项目自己的配置结构体
+// 配置结构体由各项目定义，字段用 mapstructure 标签，带 secret:"true" 的字段在 Redacted 中会被打码
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Options 加载参数
+type Options struct {
+	File      string // 配置文件路径，格式由扩展名决定
+	EnvPrefix string // 环境变量前缀，如 FORUM 时 mysql.password 对应 FORUM_MYSQL_PASSWORD；为空时不读环境变量
+}
+
+// Validator 配置结构体实现它时，每次加载后都会校验
+type Validator interface {
+	Validate() error
+}
+
+// Loader 加载 T 类型的配置，每个Loader使用独立的viper实例
+type Loader[T any] struct {
+	v    *viper.Viper
+	opts Options
+}
+
+// NewLoader 创建加载器，之后调用 Load
+func NewLoader[T any](opts Options) *Loader[T] {
+	return &Loader[T]{v: viper.New(), opts: opts}
+}
+
+// Load 读取配置文件、叠加环境变量和secrets文件，并做完整校验
+func (l *Loader[T]) Load() (*T, error) {
+	l.v.SetConfigFile(l.opts.File)
+
+	// 环境变量覆盖：FORUM_MYSQL_PASSWORD、FORUM_REDIS_PORT ...
+	if err := l.bindEnvs(); err != nil {
+		return nil, err
+	}
+
+	// 读取配置文件
+	if err := l.v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s not found", l.opts.File)
+		}
+		return nil, fmt.Errorf("parse config file %s failed: %w", l.opts.File, err)
+	}
+	return l.decode()
+}
+
+// Reload 配置文件变化后重新解析，viper已经重新读过文件，secrets文件可能也被轮换了，重新读一遍
+func (l *Loader[T]) Reload() (*T, error) {
+	return l.decode()
+}
+
+func (l *Loader[T]) decode() (*T, error) {
+	// secrets文件覆盖：FORUM_MYSQL_PASSWORD_FILE ...
+	if err := l.applySecretFiles(); err != nil {
+		return nil, fmt.Errorf("read secret file failed: %w", err)
+	}
+	// 把读取到的配置信息反序列化成快照
+	conf := new(T)
+	if err := l.v.Unmarshal(conf); err != nil {
+		return nil, fmt.Errorf("decode config file %s failed: %w", l.opts.File, err)
+	}
+	if v, ok := any(conf).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// Watch 监听配置文件，修改后调用fn，fn里一般调用 Reload
+func (l *Loader[T]) Watch(fn func()) {
+	l.v.WatchConfig()
+	l.v.OnConfigChange(func(in fsnotify.Event) {
+		fn()
+	})
+}